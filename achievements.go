@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/tsujio/game-archerfish/core"
+)
+
+type AchievementID string
+
+const (
+	AchievementShyHunter    AchievementID = "shy_hunter"
+	AchievementCentury      AchievementID = "century"
+	AchievementSharpshooter AchievementID = "sharpshooter"
+	AchievementMultiKill    AchievementID = "multi_kill"
+	AchievementDailyRoutine AchievementID = "daily_routine"
+)
+
+const (
+	shyHunterHits         = 10
+	centuryScore          = 100
+	sharpshooterAccuracy  = 0.9
+	sharpshooterMinHits   = 10
+	multiKillCount        = 3
+	multiKillWindowTicks  = 60
+	dailyRoutineDays      = 7
+	achievementsStorageID = "achievements"
+)
+
+var toastColorAchievement = color.RGBA{0xff, 0xe0, 0, 0xff}
+
+// achievementRound holds the records of the current round that the
+// achievements are judged by.
+type achievementRound struct {
+	shyHits   int
+	hits      int
+	misses    int
+	score     int
+	killTicks []uint64
+	multiKill int
+	finished  bool
+}
+
+type achievementDef struct {
+	id       AchievementID
+	achieved func(r *achievementRound, s *AchievementState) bool
+}
+
+// achievementDefs is the table of the achievements, in the order they are
+// shown in the gallery.
+var achievementDefs = []achievementDef{
+	{AchievementShyHunter, func(r *achievementRound, s *AchievementState) bool {
+		return r.shyHits >= shyHunterHits
+	}},
+	{AchievementCentury, func(r *achievementRound, s *AchievementState) bool {
+		return r.score >= centuryScore
+	}},
+	{AchievementSharpshooter, func(r *achievementRound, s *AchievementState) bool {
+		// The accuracy is judged at the end, with enough hits to rule out
+		// a lucky few shots
+		return r.finished && r.hits >= sharpshooterMinHits &&
+			float64(r.hits)/float64(r.hits+r.misses) >= sharpshooterAccuracy
+	}},
+	{AchievementMultiKill, func(r *achievementRound, s *AchievementState) bool {
+		return r.multiKill >= multiKillCount
+	}},
+	{AchievementDailyRoutine, func(r *achievementRound, s *AchievementState) bool {
+		return s.DailyChallenges >= dailyRoutineDays
+	}},
+}
+
+// AchievementState is the saved state of the achievements of a profile.
+type AchievementState struct {
+	// Unlocked holds the unix time each achievement was unlocked at
+	Unlocked map[AchievementID]int64 `json:"unlocked"`
+	// DailyChallenges counts the dates whose daily challenge was finished,
+	// the last of which is LastDailyDay in YYYY-MM-DD
+	DailyChallenges int    `json:"daily_challenges"`
+	LastDailyDay    string `json:"last_daily_day"`
+}
+
+func newAchievementState() *AchievementState {
+	return &AchievementState{
+		Unlocked: make(map[AchievementID]int64),
+	}
+}
+
+// Achievements judges the achievements from the game events and keeps the
+// unlocked ones. The states of all the profiles are saved together, unless
+// persist is false.
+type Achievements struct {
+	profile  string
+	persist  bool
+	profiles map[string]*AchievementState
+	state    *AchievementState
+	round    achievementRound
+	onUnlock func(AchievementID)
+}
+
+func newAchievements(profile string, persist bool) *Achievements {
+	a := &Achievements{
+		profile:  profile,
+		persist:  persist,
+		profiles: make(map[string]*AchievementState),
+	}
+
+	if persist {
+		if data, err := loadData(achievementsStorageID); err == nil {
+			if err := json.Unmarshal(data, &a.profiles); err != nil {
+				a.profiles = make(map[string]*AchievementState)
+			}
+		}
+	}
+
+	a.state = a.profiles[profile]
+	if a.state == nil || a.state.Unlocked == nil {
+		a.state = newAchievementState()
+		a.profiles[profile] = a.state
+	}
+
+	return a
+}
+
+func (a *Achievements) save() {
+	if !a.persist {
+		return
+	}
+	data, err := json.Marshal(a.profiles)
+	if err == nil {
+		err = saveData(achievementsStorageID, data)
+	}
+	if err != nil {
+		log.Printf("achievements: %v", err)
+	}
+}
+
+// Subscribe makes the achievements judged by the events on the bus.
+func (a *Achievements) Subscribe(bus *EventBus, onUnlock func(AchievementID)) {
+	a.onUnlock = onUnlock
+	bus.Subscribe(a.handle)
+}
+
+// StartRound clears the records of the previous round.
+func (a *Achievements) StartRound() {
+	a.round = achievementRound{}
+}
+
+func (a *Achievements) IsUnlocked(id AchievementID) bool {
+	_, ok := a.state.Unlocked[id]
+	return ok
+}
+
+func (a *Achievements) handle(e GameEvent) {
+	r := &a.round
+	r.score = e.Score
+
+	switch e.Kind {
+	case GameEventHit:
+		r.hits++
+		if e.EnemyKind == core.EnemyKindShy {
+			r.shyHits++
+		}
+	case GameEventMiss:
+		r.misses++
+	case GameEventKill:
+		var recent []uint64
+		for _, t := range r.killTicks {
+			if e.Ticks-t < multiKillWindowTicks {
+				recent = append(recent, t)
+			}
+		}
+		r.killTicks = append(recent, e.Ticks)
+		if len(r.killTicks) > r.multiKill {
+			r.multiKill = len(r.killTicks)
+		}
+	case GameEventGameOver:
+		r.finished = true
+		if e.DailyDay != "" {
+			a.addDailyChallenge(e.DailyDay)
+		}
+	}
+
+	a.judge()
+}
+
+// addDailyChallenge counts the daily challenge of the day unless it is
+// counted already. The days come in order, and the ones before the last
+// are taken as the clock set back.
+func (a *Achievements) addDailyChallenge(day string) {
+	if day <= a.state.LastDailyDay {
+		return
+	}
+	a.state.DailyChallenges++
+	a.state.LastDailyDay = day
+	a.save()
+}
+
+func (a *Achievements) judge() {
+	for _, def := range achievementDefs {
+		if a.IsUnlocked(def.id) || !def.achieved(&a.round, a.state) {
+			continue
+		}
+
+		a.state.Unlocked[def.id] = time.Now().Unix()
+		a.save()
+
+		if a.onUnlock != nil {
+			a.onUnlock(def.id)
+		}
+	}
+}
+
+func (g *Game) showAchievementToast(id AchievementID) {
+	m := g.messages()
+	g.showToast(fmt.Sprintf("%s: %s", m.AchievementUnlocked, m.AchievementTexts[id].Name), toastColorAchievement)
+}
+
+const (
+	achievementsButtonY = 55
+	achievementsItemY   = 100
+	achievementsItemH   = 60
+)
+
+func (g *Game) isAchievementsButtonTouched() bool {
+	pos := g.getTouchPosition()
+	w := textLen(g.messages().Achievements) * int(fontS.FaceOptions.Size)
+	return pos.X >= g.screenWidth-w-20 && pos.X < g.screenWidth &&
+		pos.Y >= achievementsButtonY-20 && pos.Y < achievementsButtonY+10
+}
+
+func (g *Game) drawAchievementsButton(screen *ebiten.Image) {
+	t := g.messages().Achievements
+	text.Draw(screen, t, fontS.Face, g.screenWidth-(textLen(t)+1)*int(fontS.FaceOptions.Size), achievementsButtonY, color.White)
+}
+
+func (g *Game) updateAchievements() {
+	if g.isJustTouchedOnField() && g.getTouchPosition().Y >= settingsBackY-30 {
+		g.setNextMode(GameModeTitle)
+	}
+}
+
+func (g *Game) drawAchievements(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 20, 20, float64(g.screenWidth)-20*2, screenHeight-20*2, color.RGBA{0, 0, 0, 0xa0})
+
+	m := g.messages()
+
+	t := m.Achievements
+	text.Draw(screen, t, fontM.Face, g.screenWidth/2-textLen(t)*int(fontM.FaceOptions.Size)/2, 60, color.White)
+
+	for i, def := range achievementDefs {
+		y := achievementsItemY + i*achievementsItemH
+		at := m.AchievementTexts[def.id]
+
+		mark, c := "[ ]", color.Color(color.RGBA{0x80, 0x80, 0x80, 0xff})
+		if g.achievements.IsUnlocked(def.id) {
+			mark, c = "[*]", toastColorAchievement
+		}
+		text.Draw(screen, mark, fontS.Face, settingsItemX, y, c)
+		text.Draw(screen, at.Name, fontS.Face, settingsItemX+60, y, c)
+		text.Draw(screen, at.Description, fontS.Face, settingsItemX+60, y+22, color.White)
+	}
+
+	t = m.Back
+	text.Draw(screen, t, fontS.Face, g.screenWidth/2-textLen(t)*int(fontS.FaceOptions.Size)/2, settingsBackY, color.White)
+}
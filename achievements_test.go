@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestAchievementsDailyRoutine(t *testing.T) {
+	a := newAchievements("test", false)
+	var unlocked []AchievementID
+	a.onUnlock = func(id AchievementID) {
+		unlocked = append(unlocked, id)
+	}
+
+	finish := func(day string) {
+		a.StartRound()
+		a.handle(GameEvent{Kind: GameEventGameOver, DailyDay: day})
+	}
+
+	for _, day := range []string{
+		"2023-01-01",
+		// The rounds other than the daily challenges
+		"", "",
+		// The same challenge again
+		"2023-01-01",
+		"2023-01-03",
+		// The clock set back
+		"2022-12-31",
+		"2023-01-04", "2023-01-10", "2023-02-01", "2023-02-01", "2023-02-02",
+	} {
+		finish(day)
+	}
+	if a.state.DailyChallenges != 6 || a.state.LastDailyDay != "2023-02-02" {
+		t.Fatalf("%d challenges, the last on %s, want 6, the last on 2023-02-02", a.state.DailyChallenges, a.state.LastDailyDay)
+	}
+	if a.IsUnlocked(AchievementDailyRoutine) {
+		t.Fatal("unlocked by the 6th challenge")
+	}
+
+	finish("2023-02-03")
+	if !a.IsUnlocked(AchievementDailyRoutine) || len(unlocked) != 1 || unlocked[0] != AchievementDailyRoutine {
+		t.Errorf("unlocked %v by the 7th challenge, want %v", unlocked, []AchievementID{AchievementDailyRoutine})
+	}
+}
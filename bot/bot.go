@@ -0,0 +1,302 @@
+// Package bot implements a player of the game, which aims by solving the
+// trajectory of the bullet in reverse.
+package bot
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/geom"
+)
+
+type Level int
+
+const (
+	LevelEasy Level = iota
+	LevelNormal
+	LevelHard
+)
+
+var Levels = []Level{LevelEasy, LevelNormal, LevelHard}
+
+func (l Level) String() string {
+	switch l {
+	case LevelEasy:
+		return "easy"
+	case LevelNormal:
+		return "normal"
+	case LevelHard:
+		return "hard"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLevel returns the level of the name returned by String.
+func ParseLevel(name string) (Level, bool) {
+	for _, l := range Levels {
+		if l.String() == name {
+			return l, true
+		}
+	}
+	return 0, false
+}
+
+type levelParams struct {
+	// aimNoise is the standard deviation of the hold position on the
+	// screen
+	aimNoise float64
+	// reactionTicks is the wait after a shot before picking the next
+	// target
+	reactionTicks uint64
+	// aimTicks is how long the fish is held before released
+	aimTicks uint64
+}
+
+var levelParamsTable = map[Level]levelParams{
+	LevelEasy:   {aimNoise: 8, reactionTicks: 60, aimTicks: 20},
+	LevelNormal: {aimNoise: 3, reactionTicks: 30, aimTicks: 12},
+	LevelHard:   {aimNoise: 0, reactionTicks: 5, aimTicks: 6},
+}
+
+// aimMargin is the part of the collision distance kept for the error of
+// the prediction.
+const aimMargin = 0.8
+
+type shot struct {
+	x, y        float64
+	arrivalTick uint64
+}
+
+// Bot decides the actions of the player. Its randomness is separate from
+// the world's, so that it does not change how the enemies behave.
+type Bot struct {
+	params             levelParams
+	random             *rand.Rand
+	holding            bool
+	holdTicks          uint64
+	holdX, holdY       float64
+	releaseX, releaseY float64
+	waitTicks          uint64
+	shots              []shot
+}
+
+func New(level Level, seed int64) *Bot {
+	return &Bot{
+		params: levelParamsTable[level],
+		random: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Holding reports whether the bot holds the fish.
+func (b *Bot) Holding() bool {
+	return b.holding
+}
+
+// HoldPosition returns where the bot pulls the fish to.
+func (b *Bot) HoldPosition() (float64, float64) {
+	return b.holdX, b.holdY
+}
+
+// Act returns the action in the tick before w is updated.
+func (b *Bot) Act(w *core.World) core.Action {
+	if b.holding {
+		b.holdTicks++
+
+		// Move the finger toward the aim while holding
+		rate := float64(b.holdTicks) / float64(b.params.aimTicks+1)
+		fishX, fishY := w.FishScreenPosition()
+		b.holdX = fishX + (b.releaseX-fishX)*rate
+		b.holdY = fishY + (b.releaseY-fishY)*rate
+
+		if b.holdTicks > b.params.aimTicks {
+			b.holding = false
+			b.waitTicks = b.params.reactionTicks
+			b.holdX, b.holdY = b.releaseX, b.releaseY
+			return core.Action{
+				Release:  true,
+				ReleaseX: b.releaseX,
+				ReleaseY: b.releaseY,
+			}
+		}
+		return core.Action{}
+	}
+
+	if b.waitTicks > 0 {
+		b.waitTicks--
+		return core.Action{}
+	}
+
+	if w.TimeInTicks == 0 {
+		return core.Action{}
+	}
+
+	x, y, ok := b.aim(w)
+	if !ok {
+		return core.Action{}
+	}
+
+	x += b.random.NormFloat64() * b.params.aimNoise
+	y += b.random.NormFloat64() * b.params.aimNoise
+	b.releaseX, b.releaseY = w.ClampHoldPosition(x, y)
+
+	fishX, fishY := w.FishScreenPosition()
+	b.holding = true
+	b.holdTicks = 0
+	b.holdX, b.holdY = fishX, fishY
+
+	return core.Action{
+		Touch:  true,
+		TouchX: fishX,
+		TouchY: fishY,
+	}
+}
+
+// aim picks the target and returns the hold position which hits it.
+func (b *Bot) aim(w *core.World) (float64, float64, bool) {
+	// The bullet is shot by the update aimTicks after the touch, and moves
+	// from the update
+	releaseUpdates := b.params.aimTicks + 1
+	steps := uint64(math.Ceil((core.EnemyZ - core.FishPosZInCamera) / w.Tuning.BulletVz))
+	updates := releaseUpdates + steps - 1
+	arrivalTick := w.Ticks + updates
+
+	var pending []shot
+	for _, s := range b.shots {
+		if s.arrivalTick >= w.Ticks {
+			pending = append(pending, s)
+		}
+	}
+	b.shots = pending
+
+	bestScore := 0
+	var bestX, bestY, bestHoldX, bestHoldY float64
+	for i := range w.Enemies {
+		e := &w.Enemies[i]
+		if e.Hit {
+			continue
+		}
+
+		xMin, xMax := PredictEnemyX(e, w.Tuning, updates)
+		reach := (e.R + w.Tuning.BulletR) * aimMargin
+		if (xMax-xMin)/2 >= reach {
+			// Too uncertain where a dizzy enemy will be
+			continue
+		}
+		x, y := (xMin+xMax)/2, e.Y
+
+		// Skip the enemies which the shots on the way will hit
+		targeted := false
+		for _, s := range b.shots {
+			sxMin, sxMax := PredictEnemyX(e, w.Tuning, s.arrivalTick-w.Ticks)
+			if math.Abs((sxMin+sxMax)/2-s.x) < reach && math.Abs(e.Y-s.y) < reach {
+				targeted = true
+			}
+		}
+		if targeted {
+			continue
+		}
+
+		holdX, holdY, ok := HoldPositionFor(w, x, y, float64(steps))
+		if !ok {
+			continue
+		}
+
+		if score := w.Tuning.Enemy(e.Kind).Score; score > bestScore {
+			bestScore = score
+			bestX, bestY = x, y
+			bestHoldX, bestHoldY = holdX, holdY
+		}
+	}
+
+	if bestScore == 0 {
+		return 0, 0, false
+	}
+
+	b.shots = append(b.shots, shot{x: bestX, y: bestY, arrivalTick: arrivalTick})
+
+	return bestHoldX, bestHoldY, true
+}
+
+// PredictEnemyX returns the range of the x of the enemy after the updates.
+// The range is wide when a dizzy enemy may stop or walk on the way.
+//
+// The states the enemy may be in are grouped by the velocity, each with
+// the range of the x, as the states of a velocity move together. A dizzy
+// enemy is either stopping or walking, so there are at most two groups
+// and the cost is linear in the updates.
+func PredictEnemyX(e *core.Enemy, tuning *core.Tuning, updates uint64) (float64, float64) {
+	vx0 := e.VX0
+	if e.Ticks == 0 {
+		vx0 = e.VX
+	}
+
+	type group struct {
+		xMin, xMax, vx float64
+	}
+	groups := []group{{xMin: e.X, xMax: e.X, vx: e.VX}}
+	add := func(groups []group, g group) []group {
+		for i := range groups {
+			if groups[i].vx == g.vx {
+				groups[i].xMin = math.Min(groups[i].xMin, g.xMin)
+				groups[i].xMax = math.Max(groups[i].xMax, g.xMax)
+				return groups
+			}
+		}
+		return append(groups, g)
+	}
+	for ticks := e.Ticks + 1; ticks <= e.Ticks+updates; ticks++ {
+		var next []group
+		for _, g := range groups {
+			switch e.Kind {
+			case core.EnemyKindDizzy:
+				if ticks%tuning.DizzyToggleInterval == 0 {
+					toggled := g
+					if toggled.vx == 0 {
+						toggled.vx = vx0
+					} else {
+						toggled.vx = 0
+					}
+					toggled.xMin += toggled.vx
+					toggled.xMax += toggled.vx
+					next = add(next, toggled)
+				}
+			case core.EnemyKindShy:
+				if ticks == tuning.ShyStopTicks {
+					g.vx = 0
+				} else if ticks == tuning.ShyTurnTicks {
+					g.vx = vx0 * -1
+				}
+			}
+			g.xMin += g.vx
+			g.xMax += g.vx
+			next = add(next, g)
+		}
+		groups = next
+	}
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	for _, g := range groups {
+		xMin = math.Min(xMin, g.xMin)
+		xMax = math.Max(xMax, g.xMax)
+	}
+	return xMin, xMax
+}
+
+// HoldPositionFor returns the hold position on the screen which shoots the
+// bullet at the position in the camera coordinates after the steps. It is
+// not ok when the hold position is out of the area the fish can be pulled
+// to.
+func HoldPositionFor(w *core.World, x, y, steps float64) (float64, float64, bool) {
+	fish := geom.Vec3{X: core.FishPosXInCamera, Y: core.FishPosYInCamera, Z: core.FishPosZInCamera}
+	v := geom.VelocityFor(fish, geom.Vec3{X: x, Y: y, Z: fish.Z + w.Tuning.BulletVz*steps}, w.Tuning.Gravity, steps)
+
+	fishX, fishY := w.FishScreenPosition()
+	hold := w.Shooter().HoldFor(geom.Vec2{X: fishX, Y: fishY}, v)
+
+	if cx, cy := w.ClampHoldPosition(hold.X, hold.Y); cx != hold.X || cy != hold.Y {
+		return 0, 0, false
+	}
+	return hold.X, hold.Y, true
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tsujio/game-archerfish/core"
+)
+
+// predictEnemyXByStates follows every state the enemy may be in, which is
+// exponential in the toggles of a dizzy enemy.
+func predictEnemyXByStates(e *core.Enemy, tuning *core.Tuning, updates uint64) (float64, float64) {
+	type state struct {
+		x, vx float64
+	}
+	states := []state{{x: e.X, vx: e.VX}}
+	for ticks := e.Ticks + 1; ticks <= e.Ticks+updates; ticks++ {
+		var next []state
+		for _, s := range states {
+			if ticks%tuning.DizzyToggleInterval == 0 {
+				toggled := s
+				if toggled.vx == 0 {
+					toggled.vx = e.VX0
+				} else {
+					toggled.vx = 0
+				}
+				toggled.x += toggled.vx
+				next = append(next, toggled)
+			}
+			s.x += s.vx
+			next = append(next, s)
+		}
+		states = next
+	}
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	for _, s := range states {
+		xMin = math.Min(xMin, s.x)
+		xMax = math.Max(xMax, s.x)
+	}
+	return xMin, xMax
+}
+
+func TestPredictEnemyXDizzy(t *testing.T) {
+	tuning := core.DefaultTuning()
+	tuning.DizzyToggleInterval = 10
+
+	for _, e := range []core.Enemy{
+		{Kind: core.EnemyKindDizzy, Ticks: 1, X: -300, VX: 4, VX0: 4},
+		{Kind: core.EnemyKindDizzy, Ticks: 37, X: 120, VX: 0, VX0: -4},
+		{Kind: core.EnemyKindDizzy, Ticks: 40, X: 0, VX: -4, VX0: -4},
+	} {
+		for _, updates := range []uint64{0, 1, 9, 10, 55, 120} {
+			gotMin, gotMax := PredictEnemyX(&e, tuning, updates)
+			wantMin, wantMax := predictEnemyXByStates(&e, tuning, updates)
+			if gotMin != wantMin || gotMax != wantMax {
+				t.Errorf("enemy %+v after %d updates: [%v, %v], want [%v, %v]", e, updates, gotMin, gotMax, wantMin, wantMax)
+			}
+		}
+	}
+}
+
+func TestPredictEnemyXDizzyWholeRound(t *testing.T) {
+	tuning := core.DefaultTuning()
+	tuning.DizzyToggleInterval = 10
+	e := core.Enemy{Kind: core.EnemyKindDizzy, Ticks: 1, X: 0, VX: 4, VX0: 4}
+
+	start := time.Now()
+	xMin, xMax := PredictEnemyX(&e, tuning, core.FinishTimeInTicks)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("the prediction of a whole round takes %v", d)
+	}
+	// The enemy may have walked all the way, or stopped for good at the
+	// first toggle, after walking from the ticks 2 to 9
+	if xMin != 4*8 || xMax != 4*core.FinishTimeInTicks {
+		t.Errorf("[%v, %v], want [%v, %v]", xMin, xMax, 4*8, 4*core.FinishTimeInTicks)
+	}
+}
+
+func TestPredictEnemyXShy(t *testing.T) {
+	tuning := core.DefaultTuning()
+	e := core.Enemy{Kind: core.EnemyKindShy, Ticks: 0, X: 0, VX: 4}
+
+	updates := tuning.ShyTurnTicks + 10
+	xMin, xMax := PredictEnemyX(&e, tuning, updates)
+	want := 4*float64(tuning.ShyStopTicks-1) - 4*float64(updates-tuning.ShyTurnTicks+1)
+	if xMin != want || xMax != want {
+		t.Errorf("[%v, %v], want %v", xMin, xMax, want)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+
+	"github.com/tsujio/game-archerfish/geom"
+)
+
+type cameraPose struct {
+	x, y, z float64
+}
+
+type cameraPan struct {
+	ticks    uint64
+	duration uint64
+	from, to cameraPose
+}
+
+// Camera projects camera coordinates onto the screen by a one-point
+// perspective. The camera looks along the z axis and the horizon is at
+// the screen center.
+type Camera struct {
+	ticks            uint64
+	x, y, z          float64
+	f                float64
+	centerX, centerY float64
+	offsetX, offsetY float64
+	zoom, zoomTarget float64
+	shakePower       float64
+	pan              *cameraPan
+}
+
+func newCamera(screenWidth int) *Camera {
+	return &Camera{
+		x:          0,
+		y:          -cameraHeight,
+		z:          0,
+		f:          cameraF,
+		centerX:    float64(screenWidth) / 2,
+		centerY:    float64(screenHeight) / 2,
+		zoom:       1,
+		zoomTarget: 1,
+	}
+}
+
+func (c *Camera) focalLength() float64 {
+	return c.f * c.zoom
+}
+
+// projection returns the projection of the current pose, zoom and shake.
+func (c *Camera) projection() geom.Projection {
+	return geom.Projection{
+		Eye:    geom.Vec3{X: c.x, Y: c.y, Z: c.z},
+		F:      c.focalLength(),
+		Center: geom.Vec2{X: c.centerX + c.offsetX, Y: c.centerY + c.offsetY},
+	}
+}
+
+func (c *Camera) ToScreenPosition(xInCamera, yInCamera, zInCamera float64) (float64, float64) {
+	p := c.projection()
+	return p.ToScreenPosition(xInCamera, yInCamera, zInCamera)
+}
+
+func (c *Camera) ToCameraPosition(xInScreen, yInScreen, zInCamera float64) (float64, float64) {
+	p := c.projection()
+	return p.ToCameraPosition(xInScreen, yInScreen, zInCamera)
+}
+
+// Scale returns the ratio of a length on the screen to the same length at
+// the given depth.
+func (c *Camera) Scale(zInCamera float64) float64 {
+	p := c.projection()
+	return p.Scale(zInCamera)
+}
+
+// Magnification returns how much larger things at the given depth look
+// than through the camera at its default pose.
+func (c *Camera) Magnification(zInCamera float64) float64 {
+	return c.Scale(zInCamera) * zInCamera / cameraF
+}
+
+func (c *Camera) HorizonY() float64 {
+	return c.centerY + c.offsetY
+}
+
+func (c *Camera) Shake(power float64) {
+	c.shakePower = math.Max(c.shakePower, power)
+}
+
+func (c *Camera) ZoomTo(zoom float64) {
+	c.zoomTarget = zoom
+}
+
+func (c *Camera) PanTo(x, y, z float64, duration uint64) {
+	c.pan = &cameraPan{
+		duration: duration,
+		from:     cameraPose{x: c.x, y: c.y, z: c.z},
+		to:       cameraPose{x: x, y: y, z: z},
+	}
+}
+
+func (c *Camera) Update() {
+	c.ticks++
+
+	c.zoom += (c.zoomTarget - c.zoom) * 0.1
+
+	if c.shakePower > 0.1 {
+		t := float64(c.ticks)
+		c.offsetX = c.shakePower * math.Sin(t*2.1)
+		c.offsetY = c.shakePower * math.Cos(t*2.9)
+		c.shakePower *= 0.85
+	} else {
+		c.shakePower = 0
+		c.offsetX, c.offsetY = 0, 0
+	}
+
+	if c.pan != nil {
+		c.pan.ticks++
+		r := math.Min(float64(c.pan.ticks)/float64(c.pan.duration), 1)
+		r = (1 - math.Cos(r*math.Pi)) / 2
+		c.x = c.pan.from.x + (c.pan.to.x-c.pan.from.x)*r
+		c.y = c.pan.from.y + (c.pan.to.y-c.pan.from.y)*r
+		c.z = c.pan.from.z + (c.pan.to.z-c.pan.from.z)*r
+		if c.pan.ticks >= c.pan.duration {
+			c.pan = nil
+		}
+	}
+}
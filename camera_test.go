@@ -0,0 +1,154 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// The projection of the fixed camera before Camera was introduced.
+
+func fixedToScreenPosition(screenWidth int, xInCamera, yInCamera, zInCamera float64) (float64, float64) {
+	x := xInCamera * cameraF / zInCamera
+	y := (yInCamera + cameraHeight) * cameraF / zInCamera
+	return x + float64(screenWidth)/2, y + float64(screenHeight)/2
+}
+
+func fixedToCameraPosition(screenWidth int, xInScreen, yInScreen, zInCamera float64) (float64, float64) {
+	x := (xInScreen - float64(screenWidth)/2) * zInCamera / cameraF
+	y := (yInScreen-float64(screenHeight)/2)*zInCamera/cameraF - cameraHeight
+	return x, y
+}
+
+var cameraTestDepths = []float64{fishPosZInCamera, 25, enemyZ, 333}
+
+func TestCameraDefaultMatchesFixedCamera(t *testing.T) {
+	for _, w := range []int{standardScreenWidth, wideScreenWidth} {
+		c := newCamera(w)
+		for _, z := range cameraTestDepths {
+			for x := -300.0; x <= 300; x += 37.5 {
+				for y := -200.0; y <= 50; y += 12.5 {
+					gotX, gotY := c.ToScreenPosition(x, y, z)
+					wantX, wantY := fixedToScreenPosition(w, x, y, z)
+					if gotX != wantX || gotY != wantY {
+						t.Errorf("width %d: ToScreenPosition(%v, %v, %v) = (%v, %v), want (%v, %v)", w, x, y, z, gotX, gotY, wantX, wantY)
+					}
+				}
+			}
+			for sx := 0.0; sx <= float64(w); sx += 40 {
+				for sy := 0.0; sy <= screenHeight; sy += 40 {
+					gotX, gotY := c.ToCameraPosition(sx, sy, z)
+					wantX, wantY := fixedToCameraPosition(w, sx, sy, z)
+					if gotX != wantX || gotY != wantY {
+						t.Errorf("width %d: ToCameraPosition(%v, %v, %v) = (%v, %v), want (%v, %v)", w, sx, sy, z, gotX, gotY, wantX, wantY)
+					}
+				}
+			}
+		}
+		if m := c.Magnification(enemyZ); m != 1 {
+			t.Errorf("width %d: Magnification(%v) = %v, want 1", w, enemyZ, m)
+		}
+		if y := c.HorizonY(); y != screenHeight/2 {
+			t.Errorf("width %d: HorizonY() = %v, want %v", w, y, screenHeight/2)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// checkRoundTrip checks that the screen and the camera positions convert
+// back to themselves in the current state of the camera.
+func checkRoundTrip(t *testing.T, name string, c *Camera) {
+	t.Helper()
+	for _, z := range cameraTestDepths {
+		for x := -300.0; x <= 300; x += 75 {
+			for y := -200.0; y <= 50; y += 50 {
+				sx, sy := c.ToScreenPosition(x, y, z)
+				gotX, gotY := c.ToCameraPosition(sx, sy, z)
+				if !near(gotX, x) || !near(gotY, y) {
+					t.Errorf("%s: (%v, %v, %v) round-trips to (%v, %v)", name, x, y, z, gotX, gotY)
+				}
+			}
+		}
+	}
+}
+
+func TestCameraShake(t *testing.T) {
+	c := newCamera(standardScreenWidth)
+	c.Shake(8)
+	c.Shake(3)
+	if c.shakePower != 8 {
+		t.Fatalf("shakePower = %v, want the stronger shake 8", c.shakePower)
+	}
+
+	c.Update()
+	if c.offsetX == 0 && c.offsetY == 0 {
+		t.Fatal("the camera does not move while shaking")
+	}
+	if c.HorizonY() != screenHeight/2+c.offsetY {
+		t.Errorf("HorizonY() = %v, want the shaken %v", c.HorizonY(), screenHeight/2+c.offsetY)
+	}
+	checkRoundTrip(t, "shaking", c)
+
+	for i := 0; i < 100; i++ {
+		c.Update()
+	}
+	if c.shakePower != 0 || c.offsetX != 0 || c.offsetY != 0 {
+		t.Errorf("the shake does not settle: power %v, offset (%v, %v)", c.shakePower, c.offsetX, c.offsetY)
+	}
+	wantX, wantY := newCamera(standardScreenWidth).ToScreenPosition(10, -20, enemyZ)
+	if x, y := c.ToScreenPosition(10, -20, enemyZ); x != wantX || y != wantY {
+		t.Errorf("the settled camera projects to (%v, %v), want (%v, %v)", x, y, wantX, wantY)
+	}
+}
+
+func TestCameraZoom(t *testing.T) {
+	c := newCamera(standardScreenWidth)
+	c.ZoomTo(2)
+	for i := 0; i < 300; i++ {
+		c.Update()
+	}
+	if !near(c.zoom, 2) {
+		t.Fatalf("zoom = %v, want 2", c.zoom)
+	}
+	if m := c.Magnification(enemyZ); !near(m, 2) {
+		t.Errorf("Magnification(%v) = %v, want 2", enemyZ, m)
+	}
+
+	// The zoom is around the horizon center
+	cx, cy := c.ToScreenPosition(0, -cameraHeight, enemyZ)
+	if !near(cx, standardScreenWidth/2) || !near(cy, screenHeight/2) {
+		t.Errorf("the center moves to (%v, %v) by the zoom", cx, cy)
+	}
+	checkRoundTrip(t, "zoomed", c)
+}
+
+func TestCameraPan(t *testing.T) {
+	c := newCamera(standardScreenWidth)
+	c.PanTo(30, -cameraHeight-40, -40, 240)
+
+	for i := 0; i < 120; i++ {
+		c.Update()
+	}
+	if !near(c.x, 15) || !near(c.y, -cameraHeight-20) || !near(c.z, -20) {
+		t.Errorf("the camera is at (%v, %v, %v) halfway", c.x, c.y, c.z)
+	}
+	checkRoundTrip(t, "panning", c)
+
+	for i := 0; i < 120; i++ {
+		c.Update()
+	}
+	if c.pan != nil {
+		t.Fatal("the pan does not finish")
+	}
+	if c.x != 30 || c.y != -cameraHeight-40 || c.z != -40 {
+		t.Errorf("the camera stops at (%v, %v, %v)", c.x, c.y, c.z)
+	}
+	checkRoundTrip(t, "panned", c)
+
+	// The camera having backed off, things look smaller
+	if m, want := c.Magnification(enemyZ), enemyZ/(enemyZ+40.0); !near(m, want) {
+		t.Errorf("Magnification(%v) = %v, want %v", enemyZ, m, want)
+	}
+}
@@ -0,0 +1,175 @@
+// Command archerfish-env serves the environment of package env to the
+// training scripts in other languages.
+//
+// Usage:
+//
+//	archerfish-env [-http address] [-frame-skip n] [-tuning file]
+//
+// By default, it reads a request in JSON from each line of the standard
+// input and writes the response to the standard output:
+//
+//	{"method": "reset", "seed": 1}
+//	{"method": "step", "action": {"touch": true, "touch_x": 320, "touch_y": 360}}
+//
+// Both methods respond {"observation": ..., "reward": ..., "done": ...},
+// or {"error": ...} on failure. With -http, the same requests are accepted
+// by POST /reset and POST /step on the address, without the method field.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/env"
+)
+
+type Request struct {
+	Method string     `json:"method"`
+	Seed   int64      `json:"seed"`
+	Action env.Action `json:"action"`
+}
+
+type Response struct {
+	Observation *env.Observation `json:"observation,omitempty"`
+	Reward      float64          `json:"reward"`
+	Done        bool             `json:"done"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// server serializes the requests to an environment.
+type server struct {
+	mu      sync.Mutex
+	env     *env.Env
+	started bool
+}
+
+func (s *server) handle(req *Request) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Method {
+	case "reset":
+		o := s.env.Reset(req.Seed)
+		s.started = true
+		return &Response{Observation: &o}, nil
+	case "step":
+		if !s.started {
+			return nil, errors.New("reset must be called first")
+		}
+		o, reward, done := s.env.Step(req.Action)
+		return &Response{Observation: &o, Reward: reward, Done: done}, nil
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+func (s *server) serveStdio(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var res *Response
+		var req Request
+		err := json.Unmarshal(scanner.Bytes(), &req)
+		if err == nil {
+			res, err = s.handle(&req)
+		}
+		if err != nil {
+			res = &Response{Error: err.Error()}
+		}
+
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *server) httpHandler(method string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			enc.Encode(&Response{Error: "POST only"})
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(&Response{Error: err.Error()})
+			return
+		}
+		req.Method = method
+
+		res, err := s.handle(&req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(&Response{Error: err.Error()})
+			return
+		}
+		enc.Encode(res)
+	}
+}
+
+func main() {
+	var (
+		address    = flag.String("http", "", "serve HTTP on the `address` such as 127.0.0.1:8080 instead of the standard input and output")
+		frameSkip  = flag.Int("frame-skip", 4, "number of the ticks of a step")
+		tuningPath = flag.String("tuning", "", "tuning `file` in JSON (the defaults if empty)")
+	)
+	flag.Parse()
+
+	if *frameSkip <= 0 {
+		log.Fatal("frame-skip must be positive")
+	}
+
+	tuning := core.DefaultTuning()
+	if *tuningPath != "" {
+		data, err := os.ReadFile(*tuningPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tuning, err = core.ParseTuning(data)
+		if err != nil {
+			log.Fatalf("%s: %v", *tuningPath, err)
+		}
+	}
+
+	s := &server{
+		env: env.New(&env.Options{
+			Tuning:    tuning,
+			FrameSkip: *frameSkip,
+		}),
+	}
+
+	if *address != "" {
+		http.HandleFunc("/reset", s.httpHandler("reset"))
+		http.HandleFunc("/step", s.httpHandler("step"))
+		log.Fatal(http.ListenAndServe(*address, nil))
+	}
+
+	if err := s.serveStdio(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,298 @@
+// Command archerfish-sim runs rounds of the game headless and reports
+// their statistics, for tuning the balance of the game.
+//
+// Usage:
+//
+//	archerfish-sim [flags]
+//
+// The rounds are played with the seeds from -seed on, so that the same
+// flags always give the same results. Run with -help for the flags.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/tsujio/game-archerfish/bot"
+	"github.com/tsujio/game-archerfish/core"
+)
+
+type KindStats struct {
+	Spawned int `json:"spawned"`
+	Hit     int `json:"hit"`
+	Escaped int `json:"escaped"`
+}
+
+type RoundStats struct {
+	Seed     int64                 `json:"seed"`
+	Score    int                   `json:"score"`
+	Shots    int                   `json:"shots"`
+	Hits     int                   `json:"hits"`
+	Accuracy float64               `json:"accuracy"`
+	Kinds    map[string]*KindStats `json:"kinds"`
+}
+
+type Summary struct {
+	Rounds      int                   `json:"rounds"`
+	ScoreMean   float64               `json:"score_mean"`
+	ScoreStdDev float64               `json:"score_stddev"`
+	ScoreMin    int                   `json:"score_min"`
+	ScoreP10    int                   `json:"score_p10"`
+	ScoreMedian int                   `json:"score_median"`
+	ScoreP90    int                   `json:"score_p90"`
+	ScoreMax    int                   `json:"score_max"`
+	Shots       int                   `json:"shots"`
+	Hits        int                   `json:"hits"`
+	Accuracy    float64               `json:"accuracy"`
+	Kinds       map[string]*KindStats `json:"kinds"`
+}
+
+func newKindStats() map[string]*KindStats {
+	kinds := make(map[string]*KindStats)
+	for _, kind := range core.EnemyKinds {
+		kinds[kind.String()] = &KindStats{}
+	}
+	return kinds
+}
+
+func accuracy(hits, shots int) float64 {
+	if shots == 0 {
+		return 0
+	}
+	return float64(hits) / float64(shots)
+}
+
+// runRound plays a round to the end and collects the statistics from the
+// events.
+func runRound(seed int64, tuning *core.Tuning, screenWidth int, policy Policy) *RoundStats {
+	w := core.NewWorld(seed, tuning, screenWidth)
+	stats := &RoundStats{
+		Seed:  seed,
+		Kinds: newKindStats(),
+	}
+
+	for !w.Finished() {
+		w.Update(policy.Act(w))
+
+		for _, e := range w.Events {
+			switch e.Kind {
+			case core.EventShoot:
+				stats.Shots++
+			case core.EventSpawn:
+				stats.Kinds[e.Enemy.Kind.String()].Spawned++
+			case core.EventHit:
+				stats.Hits++
+				stats.Kinds[e.Enemy.Kind.String()].Hit++
+			case core.EventEscape:
+				stats.Kinds[e.Enemy.Kind.String()].Escaped++
+			}
+		}
+	}
+
+	stats.Score = w.Score
+	stats.Accuracy = accuracy(stats.Hits, stats.Shots)
+
+	return stats
+}
+
+func percentile(sorted []int, p float64) int {
+	i := int(math.Round(p * float64(len(sorted)-1)))
+	return sorted[i]
+}
+
+func summarize(rounds []*RoundStats) *Summary {
+	s := &Summary{
+		Rounds: len(rounds),
+		Kinds:  newKindStats(),
+	}
+	if len(rounds) == 0 {
+		return s
+	}
+
+	var scores []int
+	sum := 0.0
+	for _, r := range rounds {
+		scores = append(scores, r.Score)
+		sum += float64(r.Score)
+		s.Shots += r.Shots
+		s.Hits += r.Hits
+		for name, k := range r.Kinds {
+			s.Kinds[name].Spawned += k.Spawned
+			s.Kinds[name].Hit += k.Hit
+			s.Kinds[name].Escaped += k.Escaped
+		}
+	}
+	sort.Ints(scores)
+
+	s.ScoreMean = sum / float64(len(scores))
+	for _, score := range scores {
+		s.ScoreStdDev += math.Pow(float64(score)-s.ScoreMean, 2)
+	}
+	s.ScoreStdDev = math.Sqrt(s.ScoreStdDev / float64(len(scores)))
+	s.ScoreMin = scores[0]
+	s.ScoreP10 = percentile(scores, 0.1)
+	s.ScoreMedian = percentile(scores, 0.5)
+	s.ScoreP90 = percentile(scores, 0.9)
+	s.ScoreMax = scores[len(scores)-1]
+	s.Accuracy = accuracy(s.Hits, s.Shots)
+
+	return s
+}
+
+func writeCSV(out io.Writer, rounds []*RoundStats) error {
+	w := csv.NewWriter(out)
+
+	header := []string{"seed", "score", "shots", "hits", "accuracy"}
+	for _, kind := range core.EnemyKinds {
+		for _, column := range []string{"spawned", "hit", "escaped"} {
+			header = append(header, kind.String()+"_"+column)
+		}
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range rounds {
+		record := []string{
+			strconv.FormatInt(r.Seed, 10),
+			strconv.Itoa(r.Score),
+			strconv.Itoa(r.Shots),
+			strconv.Itoa(r.Hits),
+			strconv.FormatFloat(r.Accuracy, 'f', 4, 64),
+		}
+		for _, kind := range core.EnemyKinds {
+			k := r.Kinds[kind.String()]
+			record = append(record, strconv.Itoa(k.Spawned), strconv.Itoa(k.Hit), strconv.Itoa(k.Escaped))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func writeJSON(out io.Writer, rounds []*RoundStats) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Summary *Summary      `json:"summary"`
+		Rounds  []*RoundStats `json:"rounds"`
+	}{
+		Summary: summarize(rounds),
+		Rounds:  rounds,
+	})
+}
+
+func main() {
+	var (
+		rounds     = flag.Int("rounds", 100, "number of the rounds")
+		seed       = flag.Int64("seed", 1, "seed of the first round, incremented for each round")
+		policyName = flag.String("policy", "random", "aiming `policy` (random, script, bot or idle)")
+		levelName  = flag.String("level", "normal", "`level` of the bot policy (easy, normal or hard)")
+		interval   = flag.Uint64("interval", 30, "ticks between the shots of the random policy")
+		scriptPath = flag.String("script", "", "script `file` of the script policy, with a line \"<time in ticks> <x> <y>\" per shot")
+		tuningPath = flag.String("tuning", "", "tuning `file` in JSON (the defaults if empty)")
+		difficulty = flag.String("difficulty", "normal", "`difficulty` of the rounds (easy, normal or hard)")
+		wide       = flag.Bool("wide", false, "play on the wide screen")
+		format     = flag.String("format", "csv", "output `format` (csv or json)")
+	)
+	flag.Parse()
+
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *rounds <= 0 {
+		fail(fmt.Errorf("rounds must be positive"))
+	}
+	if *interval == 0 {
+		fail(fmt.Errorf("interval must be positive"))
+	}
+	if *format != "csv" && *format != "json" {
+		fail(fmt.Errorf("invalid format %q", *format))
+	}
+
+	tuning := core.DefaultTuning()
+	if *tuningPath != "" {
+		data, err := os.ReadFile(*tuningPath)
+		if err != nil {
+			fail(err)
+		}
+		tuning, err = core.ParseTuning(data)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", *tuningPath, err))
+		}
+	}
+
+	if _, ok := core.ParseDifficulty(*difficulty); !ok {
+		fail(fmt.Errorf("invalid difficulty %q", *difficulty))
+	}
+	tuning = tuning.WithDifficulty(core.Difficulty(*difficulty))
+
+	var script []scriptedShot
+	if *policyName == "script" {
+		if *scriptPath == "" {
+			fail(fmt.Errorf("script file must be given by -script"))
+		}
+		f, err := os.Open(*scriptPath)
+		if err != nil {
+			fail(err)
+		}
+		script, err = readScript(f)
+		f.Close()
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", *scriptPath, err))
+		}
+	}
+
+	level, ok := bot.ParseLevel(*levelName)
+	if !ok {
+		fail(fmt.Errorf("invalid level %q", *levelName))
+	}
+
+	screenWidth := core.StandardScreenWidth
+	if *wide {
+		screenWidth = core.WideScreenWidth
+	}
+
+	var results []*RoundStats
+	for i := 0; i < *rounds; i++ {
+		s := *seed + int64(i)
+
+		var policy Policy
+		switch *policyName {
+		case "random":
+			policy = newRandomPolicy(s, *interval)
+		case "script":
+			policy = newScriptedPolicy(script)
+		case "bot":
+			policy = &botPolicy{bot: bot.New(level, s)}
+		case "idle":
+			policy = idlePolicy{}
+		default:
+			fail(fmt.Errorf("invalid policy %q", *policyName))
+		}
+
+		results = append(results, runRound(s, tuning, screenWidth, policy))
+	}
+
+	var err error
+	if *format == "json" {
+		err = writeJSON(os.Stdout, results)
+	} else {
+		err = writeCSV(os.Stdout, results)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
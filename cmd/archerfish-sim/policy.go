@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/tsujio/game-archerfish/bot"
+	"github.com/tsujio/game-archerfish/core"
+)
+
+// Policy decides the action of the player in each tick.
+type Policy interface {
+	Act(w *core.World) core.Action
+}
+
+// shooter holds the fish and releases it on the next tick, which is the
+// fastest way to shoot.
+type shooter struct {
+	releaseX, releaseY float64
+	holding            bool
+}
+
+func (s *shooter) shoot(w *core.World, x, y float64) core.Action {
+	fishX, fishY := w.FishScreenPosition()
+	s.releaseX, s.releaseY = w.ClampHoldPosition(x, y)
+	s.holding = true
+	return core.Action{
+		Touch:  true,
+		TouchX: fishX,
+		TouchY: fishY,
+	}
+}
+
+func (s *shooter) release() core.Action {
+	s.holding = false
+	return core.Action{
+		Release:  true,
+		ReleaseX: s.releaseX,
+		ReleaseY: s.releaseY,
+	}
+}
+
+// randomPolicy shoots toward random hold positions at a fixed interval.
+type randomPolicy struct {
+	shooter
+	random   *rand.Rand
+	interval uint64
+}
+
+func newRandomPolicy(seed int64, interval uint64) *randomPolicy {
+	return &randomPolicy{
+		random:   rand.New(rand.NewSource(seed)),
+		interval: interval,
+	}
+}
+
+func (p *randomPolicy) Act(w *core.World) core.Action {
+	if p.holding {
+		return p.release()
+	}
+	if w.TimeInTicks > 0 && w.TimeInTicks%p.interval == 0 {
+		_, fishY := w.FishScreenPosition()
+		x := p.random.Float64() * float64(w.ScreenWidth)
+		y := fishY + p.random.Float64()*(core.ScreenHeight-fishY)
+		return p.shoot(w, x, y)
+	}
+	return core.Action{}
+}
+
+type scriptedShot struct {
+	timeInTicks uint64
+	x, y        float64
+}
+
+// scriptedPolicy shoots toward the hold positions at the times in the
+// script, which has a line "<time in ticks> <x> <y>" for each shot.
+type scriptedPolicy struct {
+	shooter
+	shots []scriptedShot
+	next  int
+}
+
+func readScript(r io.Reader) ([]scriptedShot, error) {
+	var shots []scriptedShot
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		var shot scriptedShot
+		if _, err := fmt.Sscanf(s, "%d %g %g", &shot.timeInTicks, &shot.x, &shot.y); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if shot.timeInTicks == 0 {
+			return nil, fmt.Errorf("line %d: time must be positive", line)
+		}
+		shots = append(shots, shot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(shots, func(i, j int) bool {
+		return shots[i].timeInTicks < shots[j].timeInTicks
+	})
+	return shots, nil
+}
+
+func newScriptedPolicy(shots []scriptedShot) *scriptedPolicy {
+	return &scriptedPolicy{shots: shots}
+}
+
+func (p *scriptedPolicy) Act(w *core.World) core.Action {
+	if p.holding {
+		return p.release()
+	}
+	if p.next < len(p.shots) && w.TimeInTicks >= p.shots[p.next].timeInTicks {
+		shot := p.shots[p.next]
+		p.next++
+		return p.shoot(w, shot.x, shot.y)
+	}
+	return core.Action{}
+}
+
+// botPolicy aims by the bot.
+type botPolicy struct {
+	bot *bot.Bot
+}
+
+func (p *botPolicy) Act(w *core.World) core.Action {
+	return p.bot.Act(w)
+}
+
+// idlePolicy never shoots, for counting the enemies alone.
+type idlePolicy struct{}
+
+func (idlePolicy) Act(w *core.World) core.Action {
+	return core.Action{}
+}
@@ -0,0 +1,70 @@
+// Command archerfish-spritecheck validates sprite files.
+//
+// Usage:
+//
+//	archerfish-spritecheck [file or directory ...]
+//
+// Without arguments, resources/sprites is checked.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tsujio/game-archerfish/sprite"
+)
+
+func check(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := sprite.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d frames, %dx%d, %d colors\n", path, len(s.Frames), s.Width, s.Height, len(s.Palette))
+
+	return nil
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"resources/sprites"}
+	}
+
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if info.IsDir() {
+			matches, err := filepath.Glob(filepath.Join(arg, "*.txt"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			paths = append(paths, matches...)
+		} else {
+			paths = append(paths, arg)
+		}
+	}
+
+	failed := false
+	for _, path := range paths {
+		if err := check(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,28 @@
+// Command archerfish-telemetry exports the JSON Schema of the telemetry
+// events.
+//
+// Usage:
+//
+//	archerfish-telemetry > schema.json
+//
+// The wire format of the events and the schema are checked against the
+// golden files in testdata/telemetry by the tests of the telemetry
+// package, which overwrite them with -update.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tsujio/game-archerfish/telemetry"
+)
+
+func main() {
+	data, err := json.MarshalIndent(telemetry.Schema(), "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(append(data, '\n'))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tsujio/game-archerfish/telemetry"
+)
+
+// Hold is a touch and the release following it.
+type Hold struct {
+	Touch, Release telemetry.TouchRecord
+}
+
+func (h *Hold) Ticks() uint64 {
+	return h.Release.Ticks - h.Touch.Ticks
+}
+
+// Session is a play, from the initialize action to the next one.
+type Session struct {
+	PlayerID   string
+	PlayID     string
+	Difficulty string
+	// ScreenWidth is taken from the initialize, 0 in the logs before it
+	// was logged
+	ScreenWidth int
+	// Score, Shots and Hits are taken from the game over. Shots and Hits
+	// are missing in the logs before the versioned events.
+	Score, Shots, Hits *int
+	Touches            []telemetry.TouchRecord
+	Holds              []Hold
+}
+
+// Accuracy returns the ratio of the hits to the shots, and false if it is
+// unknown.
+func (s *Session) Accuracy() (float64, bool) {
+	if s.Shots == nil || s.Hits == nil || *s.Shots == 0 {
+		return 0, false
+	}
+	return float64(*s.Hits) / float64(*s.Shots), true
+}
+
+// readEvents reads the events in NDJSON. Each line is either an event, as
+// written by the local log file, or the body posted to the logging server
+// with the event under "payload". The events unknown to this version are
+// skipped.
+func readEvents(r io.Reader, f func(telemetry.Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var envelope struct {
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &envelope); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		data := scanner.Bytes()
+		if envelope.Payload != nil {
+			data = envelope.Payload
+		}
+
+		e, err := telemetry.Decode(data)
+		if errors.Is(err, telemetry.ErrUnknownAction) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		f(e)
+	}
+	return scanner.Err()
+}
+
+// sessionCollector groups the events by the play ID. Only the touches
+// while playing are taken, which are told by the mode of the records, or
+// in the older logs, by coming between the game start and the game over.
+type sessionCollector struct {
+	sessions []*Session
+	byPlayID map[string]*Session
+	playing  map[string]bool
+	pending  map[string]*telemetry.TouchRecord
+}
+
+func newSessionCollector() *sessionCollector {
+	return &sessionCollector{
+		byPlayID: make(map[string]*Session),
+		playing:  make(map[string]bool),
+		pending:  make(map[string]*telemetry.TouchRecord),
+	}
+}
+
+func (c *sessionCollector) session(playerID, playID string) *Session {
+	s := c.byPlayID[playID]
+	if s == nil {
+		s = &Session{
+			PlayerID: playerID,
+			PlayID:   playID,
+		}
+		c.byPlayID[playID] = s
+		c.sessions = append(c.sessions, s)
+	}
+	return s
+}
+
+func (c *sessionCollector) Add(e telemetry.Event) {
+	h := telemetry.HeaderOf(e)
+	s := c.session(h.PlayerID, h.PlayID)
+	if h.Difficulty != "" {
+		s.Difficulty = string(h.Difficulty)
+	}
+
+	var touches []telemetry.TouchRecord
+	switch e := e.(type) {
+	case *telemetry.SessionInit:
+		s.ScreenWidth = e.ScreenWidth
+	case *telemetry.GameStart:
+		c.playing[h.PlayID] = true
+	case *telemetry.GameOver:
+		c.playing[h.PlayID] = false
+		s.Score = &e.Score
+		if h.Version >= 1 {
+			s.Shots, s.Hits = &e.Shots, &e.Hits
+		}
+	case *telemetry.TouchBatch:
+		touches = e.Touches
+	}
+
+	for _, t := range touches {
+		playID, playing := h.PlayID, c.playing[h.PlayID]
+		if t.Mode != "" {
+			playing = t.Mode == "playing"
+		}
+		if t.PlayID != "" {
+			playID = t.PlayID
+		}
+		if !playing {
+			continue
+		}
+
+		s := c.session(h.PlayerID, playID)
+		s.Touches = append(s.Touches, t)
+
+		if t.JustTouched {
+			t := t
+			c.pending[playID] = &t
+		}
+		if t.JustReleased {
+			// The ticks restart on a mode change, so a release before its
+			// touch belongs to another mode
+			if touch := c.pending[playID]; touch != nil && touch.Ticks <= t.Ticks {
+				s.Holds = append(s.Holds, Hold{Touch: *touch, Release: t})
+			}
+			c.pending[playID] = nil
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Command archerfish-touches analyzes the touches in the logs of the game,
+// to see how real players grab and pull the fish.
+//
+// Usage:
+//
+//	archerfish-touches [flags] [log file ...]
+//
+// The logs are read in NDJSON from the files, or the standard input
+// without them, either as written by the -log-file of the game or as
+// exported from the logging server. The following files are written to
+// the -out directory:
+//
+//	touches.png   heatmap of the touch positions, with the grab zones
+//	releases.png  heatmap of the release positions, with the grab zones
+//	holds.png     histogram of the hold durations
+//	holds.csv     the counts of the histogram
+//	sessions.csv  the aim accuracy and the touches per session
+//
+// Each session is judged by the grab zone of its difficulty and screen
+// width. The heatmaps are as wide as the widest screen, the narrower
+// screens centered on it so that the fish is at the same place.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/tsujio/game-archerfish/core"
+)
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeCSV(path string, records [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.WriteAll(records)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func formatOptionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func main() {
+	var (
+		outDir     = flag.String("out", ".", "`directory` to write the results to")
+		cell       = flag.Int("cell", 8, "cell size of the heatmaps in pixels")
+		binTicks   = flag.Uint64("bin", 10, "ticks per bar of the hold histogram")
+		bins       = flag.Int("bins", 30, "number of the bars of the hold histogram, the last one for the longer holds")
+		tuningPath = flag.String("tuning", "", "tuning `file` in JSON for the grab zone (the defaults if empty)")
+		wide       = flag.Bool("wide", false, "take the logs without the screen width as played on the wide screen")
+	)
+	flag.Parse()
+
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *cell <= 0 || *binTicks == 0 || *bins <= 0 {
+		fail(fmt.Errorf("cell, bin and bins must be positive"))
+	}
+
+	tuning := core.DefaultTuning()
+	if *tuningPath != "" {
+		data, err := os.ReadFile(*tuningPath)
+		if err != nil {
+			fail(err)
+		}
+		tuning, err = core.ParseTuning(data)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", *tuningPath, err))
+		}
+	}
+
+	defaultScreenWidth := core.StandardScreenWidth
+	if *wide {
+		defaultScreenWidth = core.WideScreenWidth
+	}
+	sessionScreenWidth := func(s *Session) int {
+		if s.ScreenWidth == 0 {
+			return defaultScreenWidth
+		}
+		return s.ScreenWidth
+	}
+
+	collector := newSessionCollector()
+	read := func(name string, r io.Reader) {
+		if err := readEvents(r, collector.Add); err != nil {
+			fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if flag.NArg() == 0 {
+		read("stdin", os.Stdin)
+	}
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fail(err)
+		}
+		read(path, f)
+		f.Close()
+	}
+
+	screenWidth := 0
+	for _, s := range collector.sessions {
+		if w := sessionScreenWidth(s); w > screenWidth {
+			screenWidth = w
+		}
+	}
+	if screenWidth == 0 {
+		screenWidth = defaultScreenWidth
+	}
+
+	// The grab zone is where a touch starts holding the fish, which
+	// depends on the difficulty and the screen width of the session. The
+	// worlds are kept by them, with the outline of each zone on the
+	// heatmaps.
+	type zoneKey struct {
+		difficulty  core.Difficulty
+		screenWidth int
+	}
+	worlds := make(map[zoneKey]*core.World)
+	var zones []Zone
+	sessionWorld := func(s *Session) *core.World {
+		// The logs without the difficulty are of the normal one
+		difficulty, ok := core.ParseDifficulty(s.Difficulty)
+		if !ok {
+			difficulty = core.DifficultyNormal
+		}
+		key := zoneKey{difficulty, sessionScreenWidth(s)}
+		w := worlds[key]
+		if w == nil {
+			t := tuning.WithDifficulty(key.difficulty)
+			w = core.NewWorld(0, t, key.screenWidth)
+			worlds[key] = w
+
+			fishX, fishY := w.FishScreenPosition()
+			zones = append(zones, Zone{X: fishX + float64(screenWidth-key.screenWidth)/2, Y: fishY, R: t.TouchableR})
+		}
+		return w
+	}
+
+	touches := newHeatmap(screenWidth, core.ScreenHeight, *cell)
+	releases := newHeatmap(screenWidth, core.ScreenHeight, *cell)
+	holdCounts := make([]int, *bins)
+	totalTouches, grabs, totalHolds := 0, 0, 0
+
+	sessionRecords := [][]string{{"play_id", "player_id", "difficulty", "screen_width", "score", "shots", "hits", "accuracy", "touches", "grabs", "holds", "mean_hold_ticks"}}
+	for _, s := range collector.sessions {
+		if len(s.Touches) == 0 && s.Score == nil {
+			continue
+		}
+
+		w := sessionWorld(s)
+		offsetX := (screenWidth - w.ScreenWidth) / 2
+
+		sessionTouches, sessionGrabs := 0, 0
+		for _, t := range s.Touches {
+			if t.JustTouched {
+				touches.Add(t.X+offsetX, t.Y)
+				sessionTouches++
+				if w.IsTouchable(float64(t.X), float64(t.Y)) {
+					sessionGrabs++
+				}
+			}
+		}
+
+		var holdTicks uint64
+		for _, h := range s.Holds {
+			releases.Add(h.Release.X+offsetX, h.Release.Y)
+			holdTicks += h.Ticks()
+			bin := int(h.Ticks() / *binTicks)
+			if bin >= *bins {
+				bin = *bins - 1
+			}
+			holdCounts[bin]++
+		}
+
+		accuracy, meanHold := "", ""
+		if a, ok := s.Accuracy(); ok {
+			accuracy = strconv.FormatFloat(a, 'f', 3, 64)
+		}
+		if len(s.Holds) > 0 {
+			meanHold = strconv.FormatFloat(float64(holdTicks)/float64(len(s.Holds)), 'f', 1, 64)
+		}
+		sessionRecords = append(sessionRecords, []string{
+			s.PlayID, s.PlayerID, s.Difficulty, strconv.Itoa(w.ScreenWidth),
+			formatOptionalInt(s.Score), formatOptionalInt(s.Shots), formatOptionalInt(s.Hits), accuracy,
+			strconv.Itoa(sessionTouches), strconv.Itoa(sessionGrabs), strconv.Itoa(len(s.Holds)), meanHold,
+		})
+
+		totalTouches += sessionTouches
+		grabs += sessionGrabs
+		totalHolds += len(s.Holds)
+	}
+
+	holdRecords := [][]string{{"from_ticks", "to_ticks", "holds"}}
+	for i, c := range holdCounts {
+		to := strconv.FormatUint(uint64(i+1)**binTicks, 10)
+		if i == len(holdCounts)-1 {
+			to = ""
+		}
+		holdRecords = append(holdRecords, []string{strconv.FormatUint(uint64(i)**binTicks, 10), to, strconv.Itoa(c)})
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		fail(err)
+	}
+	outputs := []struct {
+		name  string
+		write func(path string) error
+	}{
+		{"touches.png", func(path string) error { return writePNG(path, touches.Render(zones)) }},
+		{"releases.png", func(path string) error { return writePNG(path, releases.Render(zones)) }},
+		{"holds.png", func(path string) error { return writePNG(path, renderHistogram(holdCounts)) }},
+		{"holds.csv", func(path string) error { return writeCSV(path, holdRecords) }},
+		{"sessions.csv", func(path string) error { return writeCSV(path, sessionRecords) }},
+	}
+	for _, o := range outputs {
+		if err := o.write(filepath.Join(*outDir, o.name)); err != nil {
+			fail(err)
+		}
+	}
+
+	grabRate := 0.0
+	if totalTouches > 0 {
+		grabRate = float64(grabs) / float64(totalTouches)
+	}
+	fmt.Printf("%d sessions, %d touches, %d in the grab zone (%.1f%%), %d holds\n",
+		len(sessionRecords)-1, totalTouches, grabs, grabRate*100, totalHolds)
+}
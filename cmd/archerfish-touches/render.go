@@ -0,0 +1,146 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Heatmap counts the positions in the cells of the field.
+type Heatmap struct {
+	Width, Height, Cell int
+	Counts              []int
+}
+
+func newHeatmap(width, height, cell int) *Heatmap {
+	return &Heatmap{
+		Width:  width,
+		Height: height,
+		Cell:   cell,
+		Counts: make([]int, (width/cell+1)*(height/cell+1)),
+	}
+}
+
+func (h *Heatmap) cols() int {
+	return h.Width/h.Cell + 1
+}
+
+// Add counts the position. The positions out of the field are counted in
+// the cells at the edge.
+func (h *Heatmap) Add(x, y int) {
+	x = clamp(x, 0, h.Width-1)
+	y = clamp(y, 0, h.Height-1)
+	h.Counts[y/h.Cell*h.cols()+x/h.Cell]++
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// heatColor maps 0 to 1 onto black, blue, red, yellow and white.
+func heatColor(v float64) color.RGBA {
+	stops := []color.RGBA{
+		{0x10, 0x10, 0x20, 0xff},
+		{0x20, 0x40, 0xc0, 0xff},
+		{0xe0, 0x30, 0x20, 0xff},
+		{0xff, 0xe0, 0x20, 0xff},
+		{0xff, 0xff, 0xff, 0xff},
+	}
+	v = math.Max(0, math.Min(1, v)) * float64(len(stops)-1)
+	i := int(v)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	f := v - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*f)
+	}
+	a, b := stops[i], stops[i+1]
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 0xff}
+}
+
+// Zone is a circle drawn over the heatmap.
+type Zone struct {
+	X, Y, R float64
+}
+
+// Render draws the heatmap in the logarithmic scale of the counts, with
+// the outlines of the zones.
+func (h *Heatmap) Render(zones []Zone) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, h.Width, h.Height))
+
+	maxCount := 0
+	for _, c := range h.Counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+
+	for y := 0; y < h.Height; y++ {
+		for x := 0; x < h.Width; x++ {
+			c := h.Counts[y/h.Cell*h.cols()+x/h.Cell]
+			v := 0.0
+			if maxCount > 0 {
+				v = math.Log1p(float64(c)) / math.Log1p(float64(maxCount))
+			}
+			img.SetRGBA(x, y, heatColor(v))
+		}
+	}
+
+	outline := color.RGBA{0x80, 0xff, 0x80, 0xff}
+	for _, z := range zones {
+		steps := int(2*math.Pi*z.R) + 1
+		for i := 0; i < steps; i++ {
+			a := 2 * math.Pi * float64(i) / float64(steps)
+			img.SetRGBA(int(z.X+z.R*math.Cos(a)), int(z.Y+z.R*math.Sin(a)), outline)
+		}
+		img.SetRGBA(int(z.X), int(z.Y), outline)
+	}
+
+	return img
+}
+
+const (
+	histogramBarWidth = 8
+	histogramHeight   = 200
+)
+
+// renderHistogram draws the counts as bars from the left, the last one
+// in another color for the overflow.
+func renderHistogram(counts []int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(counts)*histogramBarWidth, histogramHeight))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	maxCount := 0
+	for _, c := range counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+	if maxCount == 0 {
+		return img
+	}
+
+	for i, c := range counts {
+		col := color.RGBA{0x20, 0x40, 0xc0, 0xff}
+		if i == len(counts)-1 {
+			col = color.RGBA{0xe0, 0x30, 0x20, 0xff}
+		}
+		h := c * (histogramHeight - 10) / maxCount
+		for x := i * histogramBarWidth; x < (i+1)*histogramBarWidth-1; x++ {
+			for y := histogramHeight - h; y < histogramHeight; y++ {
+				img.SetRGBA(x, y, col)
+			}
+		}
+	}
+
+	return img
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tsujio/game-archerfish/bot"
+)
+
+// Config holds the options given on launch. The values are read from the
+// config file, the environment variables and the command-line flags, the
+// later ones overriding the earlier.
+type Config struct {
+	Seed       int64  `json:"seed"`
+	PlayerID   string `json:"player_id"`
+	Logging    bool   `json:"logging"`
+	Mode       string `json:"mode"`
+	Replay     string `json:"replay"`
+	Bot        string `json:"bot"`
+	Fullscreen bool   `json:"fullscreen"`
+	Dev        bool   `json:"dev"`
+	Tuning     string `json:"tuning"`
+	// LogFile is the file the logs are also appended to
+	LogFile string `json:"log_file"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Mode:   "title",
+		Tuning: "tuning.json",
+	}
+}
+
+var gameModeNames = map[string]GameMode{
+	"title":        GameModeTitle,
+	"playing":      GameModePlaying,
+	"settings":     GameModeSettings,
+	"achievements": GameModeAchievements,
+}
+
+// configEnvVars lists the environment variables which override the config
+// file.
+var configEnvVars = []struct {
+	name, usage string
+	apply       func(c *Config, value string) error
+}{
+	{"GAME_RAND_SEED", "random seed", func(c *Config, value string) error {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid seed %q", value)
+		}
+		c.Seed = seed
+		return nil
+	}},
+	{"GAME_PLAYER_ID", "player ID", func(c *Config, value string) error {
+		c.PlayerID = value
+		return nil
+	}},
+	{"GAME_LOGGING", "1 to send logs", func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		c.Logging = b
+		return nil
+	}},
+	{"GAME_DEV", "1 to enable the dev mode", func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		c.Dev = b
+		return nil
+	}},
+	{"GAME_TUNING", "tuning file in the dev mode", func(c *Config, value string) error {
+		c.Tuning = value
+		return nil
+	}},
+	{"GAME_LOG_FILE", "file to append the logs to", func(c *Config, value string) error {
+		c.LogFile = value
+		return nil
+	}},
+}
+
+func newConfigFlagSet(name string, c *Config, configPath *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(configPath, "config", "", "config `file` in JSON with the same keys as the flags")
+	fs.Int64Var(&c.Seed, "seed", c.Seed, "random seed (0 for time-based)")
+	fs.StringVar(&c.PlayerID, "player", c.PlayerID, "player `ID` (random if empty)")
+	fs.BoolVar(&c.Logging, "logging", c.Logging, "send logs to the server")
+	fs.StringVar(&c.Mode, "mode", c.Mode, "initial game `mode` (title, playing, settings or achievements)")
+	fs.StringVar(&c.Replay, "replay", c.Replay, "play back the touches in the NDJSON log `file`")
+	fs.StringVar(&c.Bot, "bot", c.Bot, "let the bot of the `level` (easy, normal or hard) play")
+	fs.BoolVar(&c.Fullscreen, "fullscreen", c.Fullscreen, "start in fullscreen")
+	fs.BoolVar(&c.Dev, "dev", c.Dev, "enable the dev mode, reloading the tuning and the sprites on change")
+	fs.StringVar(&c.Tuning, "tuning", c.Tuning, "tuning `file` in the dev mode")
+	fs.StringVar(&c.LogFile, "log-file", c.LogFile, "append the logs to the `file` in NDJSON, whether or not they are sent")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s [flags]\n\nFlags:\n", name)
+		fs.PrintDefaults()
+		fmt.Fprintf(out, "\nEnvironment variables (overridden by the flags):\n")
+		for _, e := range configEnvVars {
+			fmt.Fprintf(out, "  %s\n    \t%s\n", e.name, e.usage)
+		}
+	}
+	return fs
+}
+
+// parseConfig builds the config from the command-line arguments, the
+// config file given by -config and the environment variables.
+func parseConfig(name string, args []string, getenv func(string) string) (*Config, error) {
+	// Find -config first, which decides where to start from
+	var configPath string
+	fs := newConfigFlagSet(name, defaultConfig(), &configPath)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			fs.SetOutput(os.Stderr)
+			fs.Usage()
+		}
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	c := defaultConfig()
+
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+	}
+
+	for _, e := range configEnvVars {
+		if value := getenv(e.name); value != "" {
+			if err := e.apply(c, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", e.name, err)
+			}
+		}
+	}
+
+	if err := newConfigFlagSet(name, c, &configPath).Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if _, ok := gameModeNames[c.Mode]; !ok {
+		var names []string
+		for name := range gameModeNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("invalid mode %q (must be one of %s)", c.Mode, strings.Join(names, ", "))
+	}
+	if c.Bot != "" {
+		if _, ok := bot.ParseLevel(c.Bot); !ok {
+			return fmt.Errorf("invalid bot level %q", c.Bot)
+		}
+		if c.Replay != "" {
+			return fmt.Errorf("bot and replay cannot be used together")
+		}
+	}
+	// The replays are played from the recorded seed
+	if c.Replay != "" && c.Seed != 0 {
+		return fmt.Errorf("seed and replay cannot be used together")
+	}
+	if c.Dev && c.Tuning == "" {
+		return fmt.Errorf("tuning file must be given in the dev mode")
+	}
+	return nil
+}
+
+func (c *Config) GameMode() GameMode {
+	return gameModeNames[c.Mode]
+}
@@ -0,0 +1,41 @@
+// Package core implements the rules of the game without rendering nor
+// sound, so that rounds can also be simulated headless.
+package core
+
+import "github.com/tsujio/game-archerfish/geom"
+
+const (
+	StandardScreenWidth  = 640
+	WideScreenWidth      = 854
+	ScreenHeight         = 480
+	CameraF              = 50
+	CameraHeight         = 120
+	FishPosXInCamera     = 0
+	FishPosYInCamera     = 0
+	FishPosZInCamera     = CameraF
+	NormalEnemyYInScreen = ScreenHeight/2 - 50.0
+	DizzyEnemyYInScreen  = NormalEnemyYInScreen - 70
+	ShyEnemyYInScreen    = DizzyEnemyYInScreen - 70
+	EnemyZ               = 200.0
+	CountdownTicks       = 3 * 60
+	FinishTimeInTicks    = 60 * 60
+	// FishHeight is the height of the fish sprite, from the top of which
+	// the splashes of the shots rise
+	FishHeight = 50
+	// RulesVersion is raised when the same touches would play differently,
+	// such as when the random numbers are drawn differently, so that the
+	// recorded plays are replayed only by the rules they were played by.
+	// Version 1 removes the enemies gone off the screen, which stop drawing
+	// the random numbers; the plays logged without it are of version 0.
+	RulesVersion = 1
+)
+
+// NewStageProjection returns the projection from the default camera pose.
+// The rules of the game, such as where enemies enter, are defined on it.
+func NewStageProjection(screenWidth int) *geom.Projection {
+	return &geom.Projection{
+		Eye:    geom.Vec3{X: 0, Y: -CameraHeight, Z: 0},
+		F:      CameraF,
+		Center: geom.Vec2{X: float64(screenWidth) / 2, Y: ScreenHeight / 2},
+	}
+}
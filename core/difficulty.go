@@ -0,0 +1,98 @@
+package core
+
+import "math"
+
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyNormal Difficulty = "normal"
+	DifficultyHard   Difficulty = "hard"
+)
+
+var Difficulties = []Difficulty{DifficultyEasy, DifficultyNormal, DifficultyHard}
+
+// ParseDifficulty returns the difficulty of the name, if it is one of
+// Difficulties.
+func ParseDifficulty(name string) (Difficulty, bool) {
+	for _, d := range Difficulties {
+		if string(d) == name {
+			return d, true
+		}
+	}
+	return "", false
+}
+
+// DifficultyPreset scales the tuning for a difficulty.
+type DifficultyPreset struct {
+	VxScale                    float64
+	AppearanceProbabilityScale float64
+	// DizzyToggleIntervalScale above 1 makes dizzy enemies stop less often
+	DizzyToggleIntervalScale float64
+	// ShyTicksScale above 1 makes shy enemies stay longer before retreating
+	ShyTicksScale   float64
+	TouchableRScale float64
+	// LandingCircle shows where the bullet lands in the sight
+	LandingCircle bool
+}
+
+var difficultyPresets = map[Difficulty]DifficultyPreset{
+	DifficultyEasy: {
+		VxScale:                    0.7,
+		AppearanceProbabilityScale: 0.8,
+		DizzyToggleIntervalScale:   1.5,
+		ShyTicksScale:              1.5,
+		TouchableRScale:            1.4,
+		LandingCircle:              true,
+	},
+	DifficultyNormal: {
+		VxScale:                    1,
+		AppearanceProbabilityScale: 1,
+		DizzyToggleIntervalScale:   1,
+		ShyTicksScale:              1,
+		TouchableRScale:            1,
+		LandingCircle:              true,
+	},
+	DifficultyHard: {
+		VxScale:                    1.3,
+		AppearanceProbabilityScale: 1.2,
+		DizzyToggleIntervalScale:   0.7,
+		ShyTicksScale:              0.7,
+		TouchableRScale:            0.8,
+		LandingCircle:              false,
+	},
+}
+
+// Preset returns the preset of the difficulty. Unknown difficulties are
+// treated as normal.
+func (d Difficulty) Preset() DifficultyPreset {
+	if p, ok := difficultyPresets[d]; ok {
+		return p
+	}
+	return difficultyPresets[DifficultyNormal]
+}
+
+// scaleTicks scales the ticks, keeping them at least min.
+func scaleTicks(ticks uint64, scale float64, min uint64) uint64 {
+	return uint64(math.Max(float64(min), math.Round(float64(ticks)*scale)))
+}
+
+// WithDifficulty returns a copy of the tuning scaled by the preset of the
+// difficulty.
+func (t *Tuning) WithDifficulty(d Difficulty) *Tuning {
+	p := d.Preset()
+	s := *t
+
+	s.TouchableR *= p.TouchableRScale
+	// The ticks are kept in the bounds of ParseTuning
+	s.DizzyToggleInterval = scaleTicks(t.DizzyToggleInterval, p.DizzyToggleIntervalScale, minIntervalTicks)
+	s.ShyStopTicks = scaleTicks(t.ShyStopTicks, p.ShyTicksScale, 1)
+	s.ShyTurnTicks = scaleTicks(t.ShyTurnTicks, p.ShyTicksScale, s.ShyStopTicks+1)
+	for _, kind := range EnemyKinds {
+		e := s.Enemy(kind)
+		e.Vx *= p.VxScale
+		e.AppearanceProbability = math.Min(1, e.AppearanceProbability*p.AppearanceProbabilityScale)
+	}
+
+	return &s
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestWithDifficulty(t *testing.T) {
+	base := DefaultTuning()
+	for _, c := range []struct {
+		name       string
+		tuning     func(t *Tuning)
+		difficulty Difficulty
+		check      func(orig, s *Tuning) string
+	}{
+		{
+			name:       "normal",
+			difficulty: DifficultyNormal,
+			check: func(orig, s *Tuning) string {
+				if *s != *orig {
+					return "the tuning is changed"
+				}
+				return ""
+			},
+		},
+		{
+			name:       "unknown as normal",
+			difficulty: "nightmare",
+			check: func(orig, s *Tuning) string {
+				if *s != *orig {
+					return "the tuning is changed"
+				}
+				return ""
+			},
+		},
+		{
+			name:       "easy",
+			difficulty: DifficultyEasy,
+			check: func(orig, s *Tuning) string {
+				if s.TouchableR != 70 || s.DizzyToggleInterval != 90 || s.ShyStopTicks != 180 || s.ShyTurnTicks != 360 {
+					return "the radius or the ticks are not scaled"
+				}
+				if s.NormalEnemy.Vx != 1.4 || math.Abs(s.ShyEnemy.AppearanceProbability-0.08) > 1e-9 {
+					return "the enemies are not scaled"
+				}
+				return ""
+			},
+		},
+		{
+			name:       "hard",
+			difficulty: DifficultyHard,
+			check: func(orig, s *Tuning) string {
+				if s.TouchableR != 40 || s.DizzyToggleInterval != 42 || s.ShyStopTicks != 84 || s.ShyTurnTicks != 168 {
+					return "the radius or the ticks are not scaled"
+				}
+				if s.DizzyEnemy.Vx != 5.2 || math.Abs(s.DizzyEnemy.AppearanceProbability-0.24) > 1e-9 {
+					return "the enemies are not scaled"
+				}
+				return ""
+			},
+		},
+		{
+			name: "probability clamped",
+			tuning: func(t *Tuning) {
+				t.NormalEnemy.AppearanceProbability = 0.9
+			},
+			difficulty: DifficultyHard,
+			check: func(orig, s *Tuning) string {
+				if s.NormalEnemy.AppearanceProbability != 1 {
+					return "the probability exceeds 1"
+				}
+				return ""
+			},
+		},
+		{
+			name: "ticks kept in bounds",
+			tuning: func(t *Tuning) {
+				t.DizzyToggleInterval = minIntervalTicks
+				t.ShyStopTicks = 1
+				t.ShyTurnTicks = 2
+			},
+			difficulty: DifficultyHard,
+			check: func(orig, s *Tuning) string {
+				if s.DizzyToggleInterval != minIntervalTicks || s.ShyStopTicks != 1 || s.ShyTurnTicks != 2 {
+					return "the ticks are scaled out of the bounds"
+				}
+				return ""
+			},
+		},
+	} {
+		tuning := *base
+		if c.tuning != nil {
+			c.tuning(&tuning)
+		}
+		orig := tuning
+
+		s := tuning.WithDifficulty(c.difficulty)
+		if msg := c.check(&tuning, s); msg != "" {
+			t.Errorf("%s: %s: %+v", c.name, msg, *s)
+		}
+		if tuning != orig {
+			t.Errorf("%s: the original tuning is changed", c.name)
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseTuning(data); err != nil {
+			t.Errorf("%s: the scaled tuning is out of bounds: %v", c.name, err)
+		}
+	}
+}
+
+func TestParseDifficulty(t *testing.T) {
+	for _, d := range Difficulties {
+		if got, ok := ParseDifficulty(string(d)); !ok || got != d {
+			t.Errorf("ParseDifficulty(%q) = %q, %v", d, got, ok)
+		}
+	}
+	if _, ok := ParseDifficulty("nightmare"); ok {
+		t.Error("an unknown difficulty is parsed")
+	}
+}
@@ -0,0 +1,136 @@
+package core
+
+type Fish struct {
+	Ticks   uint64
+	X, Y, Z float64
+}
+
+func (f *Fish) Update() {
+	f.Ticks++
+}
+
+type Bullet struct {
+	Ticks      uint64
+	X, Y, Z    float64
+	VX, VY, VZ float64
+	R          float64
+}
+
+func (b *Bullet) Update(tuning *Tuning) {
+	b.Ticks++
+
+	b.VY += tuning.Gravity
+
+	b.X += b.VX
+	b.Y += b.VY
+	b.Z += b.VZ
+}
+
+type SplashEffect struct {
+	Ticks   uint64
+	X, Y, Z float64
+	VX, VY  float64
+}
+
+func (e *SplashEffect) Update(tuning *Tuning) {
+	e.Ticks++
+
+	e.VY += tuning.Gravity
+
+	e.X += e.VX
+	e.Y += e.VY
+}
+
+type EnemyKind int
+
+const (
+	EnemyKindNormal EnemyKind = iota
+	EnemyKindDizzy
+	EnemyKindShy
+)
+
+// EnemyKinds lists the kinds in the order they are spawned.
+var EnemyKinds = []EnemyKind{EnemyKindNormal, EnemyKindDizzy, EnemyKindShy}
+
+func (k EnemyKind) String() string {
+	switch k {
+	case EnemyKindNormal:
+		return "normal"
+	case EnemyKindDizzy:
+		return "dizzy"
+	case EnemyKindShy:
+		return "shy"
+	default:
+		return "unknown"
+	}
+}
+
+// YInScreen returns the height of the scaffold the enemies of the kind walk
+// on.
+func (k EnemyKind) YInScreen() float64 {
+	switch k {
+	case EnemyKindDizzy:
+		return DizzyEnemyYInScreen
+	case EnemyKindShy:
+		return ShyEnemyYInScreen
+	default:
+		return NormalEnemyYInScreen
+	}
+}
+
+type Enemy struct {
+	Ticks       uint64
+	Kind        EnemyKind
+	Hit         bool
+	X, Y, Z     float64
+	VX, VY, VX0 float64
+	R           float64
+}
+
+func (e *Enemy) Update(w *World) {
+	if e.Ticks == 0 {
+		e.VX0 = e.VX
+	}
+
+	e.Ticks++
+
+	tuning := w.Tuning
+
+	if e.Hit {
+		e.VY += tuning.Gravity
+		e.Y += e.VY
+		return
+	}
+
+	switch e.Kind {
+	case EnemyKindDizzy:
+		if e.Ticks%tuning.DizzyToggleInterval == 0 && w.Random.Int()%2 == 0 {
+			if e.VX == 0 {
+				e.VX = e.VX0
+			} else {
+				e.VX = 0
+			}
+		}
+	case EnemyKindShy:
+		if e.Ticks == tuning.ShyStopTicks {
+			e.VX = 0
+		} else if e.Ticks == tuning.ShyTurnTicks {
+			e.VX = e.VX0 * -1
+		}
+	}
+
+	e.X += e.VX
+}
+
+// IsFacingRight reports whether the enemy walks or, when stopping, is
+// about to walk to the right.
+func (e *Enemy) IsFacingRight() bool {
+	return e.VX > 0 || e.VX == 0 && e.VX0 > 0
+}
+
+// Leaf is a decoration floating on the water under the scaffolds.
+type Leaf struct {
+	X, Y, Z        float64
+	ScaleX, ScaleY float64
+	Rotate         float64
+}
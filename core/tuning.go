@@ -0,0 +1,139 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type EnemyTuning struct {
+	AppearanceProbability float64 `json:"appearance_probability"`
+	Vx                    float64 `json:"vx"`
+	R                     float64 `json:"r"`
+	Score                 int     `json:"score"`
+}
+
+// Tuning holds the parameters for the game balance.
+type Tuning struct {
+	Gravity             float64     `json:"gravity"`
+	TouchableR          float64     `json:"touchable_r"`
+	BulletR             float64     `json:"bullet_r"`
+	BulletSpeed         float64     `json:"bullet_speed"`
+	BulletVz            float64     `json:"bullet_vz"`
+	SpawnInterval       uint64      `json:"spawn_interval"`
+	DizzyToggleInterval uint64      `json:"dizzy_toggle_interval"`
+	ShyStopTicks        uint64      `json:"shy_stop_ticks"`
+	ShyTurnTicks        uint64      `json:"shy_turn_ticks"`
+	NormalEnemy         EnemyTuning `json:"normal_enemy"`
+	DizzyEnemy          EnemyTuning `json:"dizzy_enemy"`
+	ShyEnemy            EnemyTuning `json:"shy_enemy"`
+}
+
+// DefaultTuning returns the tuning the game is released with.
+func DefaultTuning() *Tuning {
+	return &Tuning{
+		Gravity:             0.5,
+		TouchableR:          50,
+		BulletR:             10,
+		BulletSpeed:         40,
+		BulletVz:            3,
+		SpawnInterval:       60,
+		DizzyToggleInterval: 60,
+		ShyStopTicks:        120,
+		ShyTurnTicks:        240,
+		NormalEnemy: EnemyTuning{
+			AppearanceProbability: 0.25,
+			Vx:                    2.0,
+			R:                     40,
+			Score:                 1,
+		},
+		DizzyEnemy: EnemyTuning{
+			AppearanceProbability: 0.20,
+			Vx:                    4.0,
+			R:                     35,
+			Score:                 3,
+		},
+		ShyEnemy: EnemyTuning{
+			AppearanceProbability: 0.10,
+			Vx:                    4.0,
+			R:                     30,
+			Score:                 5,
+		},
+	}
+}
+
+func (t *Tuning) Enemy(kind EnemyKind) *EnemyTuning {
+	switch kind {
+	case EnemyKindDizzy:
+		return &t.DizzyEnemy
+	case EnemyKindShy:
+		return &t.ShyEnemy
+	default:
+		return &t.NormalEnemy
+	}
+}
+
+// ParseTuning reads the tuning in JSON. The fields not in data keep the
+// default values.
+func ParseTuning(data []byte) (*Tuning, error) {
+	t := DefaultTuning()
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(t); err != nil {
+		return nil, err
+	}
+
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// minIntervalTicks is the shortest interval of the spawns and of the
+// dizzy enemies. Shorter ones flood the screen with enemies, or make the
+// dizzy ones flicker rather than walk.
+const minIntervalTicks = 10
+
+func (t *Tuning) validate() error {
+	for name, v := range map[string]float64{
+		"gravity":      t.Gravity,
+		"touchable_r":  t.TouchableR,
+		"bullet_r":     t.BulletR,
+		"bullet_speed": t.BulletSpeed,
+		"bullet_vz":    t.BulletVz,
+	} {
+		if v <= 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	}
+	for _, c := range []struct {
+		name     string
+		v        uint64
+		min, max uint64
+	}{
+		{"spawn_interval", t.SpawnInterval, minIntervalTicks, FinishTimeInTicks},
+		{"dizzy_toggle_interval", t.DizzyToggleInterval, minIntervalTicks, FinishTimeInTicks},
+		{"shy_stop_ticks", t.ShyStopTicks, 1, FinishTimeInTicks},
+		// Shy enemies turn after stopping
+		{"shy_turn_ticks", t.ShyTurnTicks, t.ShyStopTicks + 1, FinishTimeInTicks},
+	} {
+		if c.v < c.min || c.v > c.max {
+			return fmt.Errorf("%s must be in [%d, %d]", c.name, c.min, c.max)
+		}
+	}
+	for name, e := range map[string]*EnemyTuning{
+		"normal_enemy": &t.NormalEnemy,
+		"dizzy_enemy":  &t.DizzyEnemy,
+		"shy_enemy":    &t.ShyEnemy,
+	} {
+		if e.AppearanceProbability < 0 || e.AppearanceProbability > 1 {
+			return fmt.Errorf("%s.appearance_probability must be in [0, 1]", name)
+		}
+		if e.R <= 0 {
+			return fmt.Errorf("%s.r must be positive", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package core
+
+import "testing"
+
+func TestParseTuningBounds(t *testing.T) {
+	for _, c := range []struct {
+		json string
+		ok   bool
+	}{
+		{`{}`, true},
+		{`{"spawn_interval": 10, "dizzy_toggle_interval": 10}`, true},
+		{`{"spawn_interval": 0}`, false},
+		{`{"spawn_interval": 9}`, false},
+		{`{"spawn_interval": 3601}`, false},
+		{`{"dizzy_toggle_interval": 0}`, false},
+		{`{"dizzy_toggle_interval": 1}`, false},
+		{`{"dizzy_toggle_interval": 3600}`, true},
+		{`{"shy_stop_ticks": 0}`, false},
+		{`{"shy_stop_ticks": 1, "shy_turn_ticks": 2}`, true},
+		{`{"shy_stop_ticks": 240, "shy_turn_ticks": 240}`, false},
+		{`{"shy_stop_ticks": 300, "shy_turn_ticks": 240}`, false},
+		{`{"shy_turn_ticks": 3601}`, false},
+		{`{"gravity": 0}`, false},
+		{`{"normal_enemy": {"appearance_probability": 1.5}}`, false},
+	} {
+		_, err := ParseTuning([]byte(c.json))
+		if ok := err == nil; ok != c.ok {
+			t.Errorf("ParseTuning(%s): err = %v, want ok %v", c.json, err, c.ok)
+		}
+	}
+}
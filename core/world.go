@@ -0,0 +1,365 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/tsujio/game-archerfish/geom"
+)
+
+type EventKind int
+
+const (
+	// EventTimeStart is emitted when the countdown ends
+	EventTimeStart EventKind = iota
+	EventShoot
+	// EventMiss is emitted when a bullet falls into the water
+	EventMiss
+	EventSpawn
+	EventHit
+	// EventSplash is emitted when a hit enemy falls into the water
+	EventSplash
+	// EventEscape is emitted when an enemy leaves the screen unhit
+	EventEscape
+)
+
+// Event is what happened in a tick. Enemy is set for the events about an
+// enemy, and Bullet for the ones about a bullet.
+type Event struct {
+	Kind   EventKind
+	Enemy  Enemy
+	Bullet Bullet
+	Score  int
+}
+
+// Action is what the player does in a tick. The positions are on the
+// screen of the default camera pose.
+type Action struct {
+	// Touch starts holding the fish if (TouchX, TouchY) is on it
+	Touch          bool
+	TouchX, TouchY float64
+	// Release shoots toward the hold position (ReleaseX, ReleaseY)
+	Release            bool
+	ReleaseX, ReleaseY float64
+}
+
+// World is the state of a round.
+type World struct {
+	Tuning        *Tuning
+	ScreenWidth   int
+	Projection    *geom.Projection
+	Random        *rand.Rand
+	Ticks         uint64
+	TimeInTicks   uint64
+	Hold          bool
+	Score         int
+	Combo         int
+	Fish          Fish
+	Bullets       []Bullet
+	SplashEffects []SplashEffect
+	Enemies       []Enemy
+	Leaves        []Leaf
+	// Events holds what happened in the last Update
+	Events []Event
+}
+
+func NewWorld(seed int64, tuning *Tuning, screenWidth int) *World {
+	w := &World{
+		Tuning:      tuning,
+		ScreenWidth: screenWidth,
+		Projection:  NewStageProjection(screenWidth),
+		Random:      rand.New(rand.NewSource(seed)),
+		Fish: Fish{
+			X: FishPosXInCamera,
+			Y: FishPosYInCamera,
+			Z: FishPosZInCamera,
+		},
+	}
+
+	for _, baseY := range []float64{NormalEnemyYInScreen, DizzyEnemyYInScreen, ShyEnemyYInScreen} {
+		x := -50.0
+		for x < float64(screenWidth) {
+			x += 100 + w.Random.NormFloat64()*20
+			if baseY == ShyEnemyYInScreen {
+				if x > w.ShyScaffoldWidth() && x < float64(screenWidth)-w.ShyScaffoldWidth() {
+					continue
+				}
+			}
+			xInCamera, yInCamera := w.Projection.ToCameraPosition(x, baseY+13+w.Random.NormFloat64()*1, EnemyZ)
+			w.Leaves = append(w.Leaves, Leaf{
+				X:      xInCamera,
+				Y:      yInCamera,
+				Z:      EnemyZ,
+				ScaleX: (1 + w.Random.NormFloat64()*0.1) * float64(w.Random.Int()%2*2-1),
+				ScaleY: 1 + w.Random.NormFloat64()*0.1,
+			})
+		}
+	}
+
+	return w
+}
+
+// ShyScaffoldWidth returns the width of each stub of the top scaffold.
+// On wide screens the stubs grow so that the gap between them stays the
+// same.
+func (w *World) ShyScaffoldWidth() float64 {
+	return 90 + float64(w.ScreenWidth-StandardScreenWidth)/2
+}
+
+// FishScreenPosition returns the position of the fish on the screen.
+func (w *World) FishScreenPosition() (float64, float64) {
+	return w.Projection.ToScreenPosition(FishPosXInCamera, FishPosYInCamera, FishPosZInCamera)
+}
+
+// Shooter returns how the pull of the fish turns into the velocity of the
+// bullet. Pulling the fish to the bottom of the screen shoots at the full
+// speed.
+func (w *World) Shooter() *geom.Shooter {
+	_, fishY := w.FishScreenPosition()
+	return &geom.Shooter{
+		Speed:    w.Tuning.BulletSpeed,
+		FullPull: ScreenHeight - fishY,
+		VZ:       w.Tuning.BulletVz,
+	}
+}
+
+// IsTouchable reports whether the fish can be held by touching the
+// position on the screen.
+func (w *World) IsTouchable(x, y float64) bool {
+	fishX, fishY := w.FishScreenPosition()
+	return math.Pow(x-fishX, 2)+math.Pow(y-fishY, 2) < math.Pow(w.Tuning.TouchableR, 2)
+}
+
+// ClampHoldPosition limits the position where the fish is pulled to into
+// the screen below the fish.
+func (w *World) ClampHoldPosition(x, y float64) (float64, float64) {
+	_, fishY := w.FishScreenPosition()
+	if x < 0 {
+		x = 0
+	}
+	if x > float64(w.ScreenWidth) {
+		x = float64(w.ScreenWidth)
+	}
+	if y < fishY {
+		y = fishY
+	}
+	if y > ScreenHeight {
+		y = ScreenHeight
+	}
+	return x, y
+}
+
+// NewBullet returns the bullet shot by releasing the fish pulled to the
+// position on the screen. The farther it is pulled, the faster the bullet
+// is.
+func (w *World) NewBullet(holdX, holdY float64) Bullet {
+	fishX, fishY := w.FishScreenPosition()
+	v := w.Shooter().Velocity(geom.Vec2{X: fishX, Y: fishY}, geom.Vec2{X: holdX, Y: holdY})
+
+	return Bullet{
+		X:  FishPosXInCamera,
+		Y:  FishPosYInCamera,
+		Z:  FishPosZInCamera,
+		VX: v.X,
+		VY: v.Y,
+		VZ: v.Z,
+		R:  w.Tuning.BulletR,
+	}
+}
+
+// PredictHit returns the index of the first enemy the bullet will hit and
+// the ticks until then, supposing the enemies keep their current
+// velocities. It is not ok when the bullet falls into the water first.
+func (w *World) PredictHit(b Bullet) (int, uint64, bool) {
+	p := geom.Vec3{X: b.X, Y: b.Y, Z: b.Z}
+	v := geom.Vec3{X: b.VX, Y: b.VY, Z: b.VZ}
+	for ticks := uint64(1); ; ticks++ {
+		q := geom.PositionAfter(p, v, w.Tuning.Gravity, float64(ticks))
+		if q.Y > 0 {
+			return 0, 0, false
+		}
+		for i := range w.Enemies {
+			e := &w.Enemies[i]
+			if e.Hit {
+				continue
+			}
+			ep := geom.Vec3{X: e.X + e.VX*float64(ticks), Y: e.Y, Z: e.Z}
+			if q.Sub(ep).Len() < e.R+b.R {
+				return i, ticks, true
+			}
+		}
+	}
+}
+
+// Finished reports whether the time is up.
+func (w *World) Finished() bool {
+	return w.TimeInTicks >= FinishTimeInTicks
+}
+
+func (w *World) emit(e Event) {
+	w.Events = append(w.Events, e)
+}
+
+func (w *World) splash(x, y, z, r float64) {
+	for i := 0; i < 5; i++ {
+		w.SplashEffects = append(w.SplashEffects, SplashEffect{
+			X:  x,
+			Y:  y,
+			Z:  z,
+			VX: r * math.Cos(math.Pi*w.Random.Float64()),
+			VY: -r * math.Sin(math.Pi*w.Random.Float64()),
+		})
+	}
+}
+
+// Update advances the round by a tick.
+func (w *World) Update(action Action) {
+	w.Events = nil
+
+	w.Ticks++
+
+	if w.Ticks > CountdownTicks {
+		if w.TimeInTicks == 0 {
+			w.emit(Event{Kind: EventTimeStart})
+		}
+		w.TimeInTicks++
+	}
+
+	if w.TimeInTicks > 0 && action.Touch && w.IsTouchable(action.TouchX, action.TouchY) {
+		w.Hold = true
+	}
+
+	if w.Hold && action.Release {
+		w.Hold = false
+
+		bullet := w.NewBullet(action.ReleaseX, action.ReleaseY)
+		w.Bullets = append(w.Bullets, bullet)
+		w.emit(Event{Kind: EventShoot, Bullet: bullet})
+
+		for i := 0; i < 5; i++ {
+			w.SplashEffects = append(w.SplashEffects, SplashEffect{
+				X:  w.Fish.X,
+				Y:  w.Fish.Y - FishHeight/2,
+				Z:  w.Fish.Z,
+				VX: 5.0 * math.Cos(math.Pi*w.Random.Float64()),
+				VY: -10.0 * math.Sin(math.Pi*w.Random.Float64()),
+			})
+		}
+	}
+
+	// Enemy enter
+	if w.Ticks%w.Tuning.SpawnInterval == 0 {
+		for _, kind := range EnemyKinds {
+			param := w.Tuning.Enemy(kind)
+			if w.Random.Float64() < param.AppearanceProbability {
+				var xInScreen float64
+				if w.Random.Int()%2 == 0 {
+					xInScreen = -50
+				} else {
+					xInScreen = float64(w.ScreenWidth) + 50
+				}
+
+				vx := param.Vx
+				if xInScreen > 0 {
+					vx *= -1
+				}
+
+				x, y := w.Projection.ToCameraPosition(xInScreen, kind.YInScreen(), EnemyZ)
+
+				enemy := Enemy{
+					Kind: kind,
+					X:    x,
+					Y:    y,
+					Z:    EnemyZ,
+					VX:   vx,
+					R:    param.R,
+				}
+				w.Enemies = append(w.Enemies, enemy)
+				w.emit(Event{Kind: EventSpawn, Enemy: enemy})
+			}
+		}
+	}
+
+	// Fish
+	w.Fish.Update()
+
+	// Bullets
+	var newBullets []Bullet
+	for i := range w.Bullets {
+		bullet := &w.Bullets[i]
+
+		bullet.Update(w.Tuning)
+
+		if bullet.Y > 0 {
+			w.Combo = 0
+			w.splash(bullet.X, 0, bullet.Z, 10)
+			w.emit(Event{Kind: EventMiss, Bullet: *bullet})
+		} else {
+			newBullets = append(newBullets, *bullet)
+		}
+	}
+	w.Bullets = newBullets
+
+	// SplashEffects
+	var newSplashEffects []SplashEffect
+	for i := range w.SplashEffects {
+		effect := &w.SplashEffects[i]
+
+		effect.Update(w.Tuning)
+
+		if effect.Y <= 100 {
+			newSplashEffects = append(newSplashEffects, *effect)
+		}
+	}
+	w.SplashEffects = newSplashEffects
+
+	// Enemies
+	var newEnemies []Enemy
+	for i := range w.Enemies {
+		enemy := &w.Enemies[i]
+
+		enemy.Update(w)
+
+		if enemy.Y > 0 {
+			w.splash(enemy.X, 0, enemy.Z, 15)
+			w.emit(Event{Kind: EventSplash, Enemy: *enemy})
+			continue
+		}
+
+		// The enemies off the screen are removed, and no more draw the
+		// random numbers since RulesVersion 1
+		x, _ := w.Projection.ToScreenPosition(enemy.X, enemy.Y, enemy.Z)
+		if x >= -50 && x <= float64(w.ScreenWidth)+50 {
+			newEnemies = append(newEnemies, *enemy)
+		} else if !enemy.Hit {
+			w.emit(Event{Kind: EventEscape, Enemy: *enemy})
+		}
+	}
+	w.Enemies = newEnemies
+
+	// Bullet and enemy collision
+	newBullets = nil
+	for i := range w.Bullets {
+		b := &w.Bullets[i]
+		hit := false
+		for j := range w.Enemies {
+			e := &w.Enemies[j]
+			if !e.Hit && math.Pow(e.X-b.X, 2)+math.Pow(e.Y-b.Y, 2)+math.Pow(e.Z-b.Z, 2) < math.Pow(e.R+b.R, 2) {
+				e.Hit = true
+				hit = true
+
+				score := w.Tuning.Enemy(e.Kind).Score
+				w.Score += score
+				w.Combo++
+
+				w.emit(Event{Kind: EventHit, Enemy: *e, Bullet: *b, Score: score})
+
+				break
+			}
+		}
+		if !hit {
+			newBullets = append(newBullets, *b)
+		}
+	}
+	w.Bullets = newBullets
+}
@@ -0,0 +1,82 @@
+package core
+
+import "testing"
+
+// newQuietWorld returns a world in which no enemy appears by itself.
+func newQuietWorld() *World {
+	t := DefaultTuning()
+	t.NormalEnemy.AppearanceProbability = 0
+	t.DizzyEnemy.AppearanceProbability = 0
+	t.ShyEnemy.AppearanceProbability = 0
+	return NewWorld(1, t, StandardScreenWidth)
+}
+
+func (w *World) enterEnemy(kind EnemyKind, xInScreen, vx float64) {
+	x, y := w.Projection.ToCameraPosition(xInScreen, kind.YInScreen(), EnemyZ)
+	w.Enemies = append(w.Enemies, Enemy{
+		Kind: kind,
+		X:    x,
+		Y:    y,
+		Z:    EnemyZ,
+		VX:   vx,
+		R:    w.Tuning.Enemy(kind).R,
+	})
+}
+
+// The enemies were never removed before the escape was introduced, as the
+// condition to keep them was always true. They are now removed once off
+// the margin of the screen, where they spawn.
+func TestWorldCullsEscapedEnemies(t *testing.T) {
+	w := newQuietWorld()
+	w.enterEnemy(EnemyKindShy, -50, w.Tuning.ShyEnemy.Vx)
+	w.enterEnemy(EnemyKindNormal, float64(w.ScreenWidth)+50, -w.Tuning.NormalEnemy.Vx)
+
+	escapes := 0
+	var escapeTicks uint64
+	for i := uint64(1); i <= w.Tuning.ShyTurnTicks*3; i++ {
+		w.Update(Action{})
+		for _, e := range w.Events {
+			if e.Kind != EventEscape {
+				continue
+			}
+			escapes++
+			escapeTicks = i
+			if e.Enemy.Kind != EnemyKindShy {
+				t.Errorf("the %v enemy escapes at %d ticks while on the screen", e.Enemy.Kind, i)
+			}
+		}
+		if i <= w.Tuning.ShyTurnTicks && len(w.Enemies) != 2 {
+			t.Fatalf("%d enemies at %d ticks, before the shy one turns back", len(w.Enemies), i)
+		}
+	}
+
+	if escapes != 1 {
+		t.Fatalf("%d escapes, want 1", escapes)
+	}
+	// The shy enemy walks back as many ticks as it walked in
+	if want := w.Tuning.ShyTurnTicks + w.Tuning.ShyStopTicks; escapeTicks < want-1 || escapeTicks > want+1 {
+		t.Errorf("the shy enemy escapes at %d ticks, want about %d", escapeTicks, want)
+	}
+	for _, e := range w.Enemies {
+		if e.Kind == EnemyKindShy {
+			t.Error("the escaped shy enemy is kept")
+		}
+	}
+}
+
+// A hit enemy which goes off the screen is not an escape.
+func TestWorldDoesNotEscapeHitEnemies(t *testing.T) {
+	w := newQuietWorld()
+	w.enterEnemy(EnemyKindNormal, -60, 0)
+	w.Enemies[0].Hit = true
+
+	w.Update(Action{})
+	if len(w.Enemies) != 0 {
+		t.Fatal("the hit enemy off the screen is kept")
+	}
+	for _, e := range w.Events {
+		if e.Kind == EventEscape {
+			t.Error("the hit enemy escapes")
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// The daily challenge is a round of the same world for all the players on
+// a date, in the local time. It is played at the normal difficulty
+// whatever the chosen one is, so that the scores compare, and each date
+// has its own ranking.
+
+const (
+	dailyButtonY = 85
+	dayLayout    = "2006-01-02"
+)
+
+func today() string {
+	return time.Now().Format(dayLayout)
+}
+
+// dailySeed returns the seed of the daily challenge of the day in
+// YYYY-MM-DD, which is the date as the number YYYYMMDD.
+func dailySeed(day string) int64 {
+	seed, _ := strconv.ParseInt(strings.ReplaceAll(day, "-", ""), 10, 64)
+	return seed
+}
+
+func (g *Game) isDailyButtonTouched() bool {
+	pos := g.getTouchPosition()
+	w := textLen(g.messages().Daily) * int(fontS.FaceOptions.Size)
+	return pos.X >= g.screenWidth-w-20 && pos.X < g.screenWidth &&
+		pos.Y >= dailyButtonY-20 && pos.Y < dailyButtonY+10
+}
+
+func (g *Game) drawDailyButton(screen *ebiten.Image) {
+	t := g.messages().Daily
+	text.Draw(screen, t, fontS.Face, g.screenWidth-(textLen(t)+1)*int(fontS.FaceOptions.Size), dailyButtonY, color.White)
+}
+
+// startDailyChallenge starts a new play on the world of today's challenge.
+func (g *Game) startDailyChallenge() {
+	g.initializePlay(today())
+	g.startRound()
+}
+
+// drawDailyLabel tells the rounds of the daily challenge, under the score.
+func (g *Game) drawDailyLabel(screen *ebiten.Image) {
+	t := fmt.Sprintf("%s %s", g.messages().Daily, g.dailyDay)
+	text.Draw(screen, t, fontS.Face, g.screenWidth-(textLen(t)+1)*int(fontS.FaceOptions.Size), 40, color.RGBA{0xff, 0xe0, 0, 0xff})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/telemetry"
+	logging "github.com/tsujio/game-logging-server/client"
+)
+
+func TestDailySeed(t *testing.T) {
+	if s := dailySeed("2023-01-08"); s != 20230108 {
+		t.Errorf("dailySeed = %d, want 20230108", s)
+	}
+	if dailySeed(today()) == 0 {
+		t.Errorf("no seed for today %s", today())
+	}
+}
+
+func TestDailyChallengePlay(t *testing.T) {
+	logging.Disable()
+
+	settings := defaultSettings()
+	settings.Difficulty = core.DifficultyHard
+	g := &Game{
+		playerID:        "player",
+		settings:        settings,
+		tuning:          core.DefaultTuning(),
+		input:           &scriptedInput{},
+		fixedRandomSeed: 1,
+	}
+	g.achievements = newAchievements("test", false)
+
+	// The worlds of the same seed draw the same numbers
+	seeded := func(seed int64) bool {
+		w := core.NewWorld(seed, g.roundTuning(), g.screenWidth)
+		return w.Random.Int63() == g.world.Random.Int63()
+	}
+
+	g.initializePlay("2023-01-08")
+	if !seeded(20230108) {
+		t.Error("the daily challenge is not seeded by the date")
+	}
+	if *g.world.Tuning != *core.DefaultTuning() {
+		t.Error("the daily challenge is not at the normal difficulty")
+	}
+	if name := g.rankingName(); name != "archerfish-daily-2023-01-08" {
+		t.Errorf("the daily challenge is ranked by %s", name)
+	}
+	if d := telemetry.HeaderOf(g.logEncoder().Stamp(&telemetry.GameStart{})).Difficulty; d != core.DifficultyNormal {
+		t.Errorf("the daily challenge is logged at %s", d)
+	}
+
+	// The next play is not
+	g.initialize()
+	if g.dailyDay != "" || !seeded(1) || g.rankingName() != "archerfish-hard" {
+		t.Errorf("the play after the challenge is of day %q, ranked by %s", g.dailyDay, g.rankingName())
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/tsujio/game-archerfish/bot"
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/telemetry"
+)
+
+const (
+	// demoIdleTicks is how long the title waits for the player before the
+	// demo starts
+	demoIdleTicks   = 15 * 60
+	demoLengthTicks = core.CountdownTicks + 30*60
+)
+
+// Demo is a round played by the bot on the title screen while the player
+// is away. It runs on its own world, so it never sends logs nor registers
+// the score.
+type Demo struct {
+	world *core.World
+	bot   *bot.Bot
+}
+
+func newDemo(g *Game) *Demo {
+	seed := time.Now().UnixNano()
+	return &Demo{
+		world: core.NewWorld(seed, g.roundTuning(), g.screenWidth),
+		bot:   bot.New(bot.LevelNormal, seed),
+	}
+}
+
+func (d *Demo) Update() {
+	d.world.Update(d.bot.Act(d.world))
+}
+
+func (d *Demo) Finished() bool {
+	return d.world.Ticks >= demoLengthTicks || d.world.Finished()
+}
+
+func (g *Game) updateTitle() {
+	if g.demo != nil {
+		if g.input.IsJustTouched() {
+			g.demo = nil
+			g.setNextMode(GameModeTitle)
+			return
+		}
+
+		g.demo.Update()
+		if g.demo.Finished() {
+			g.demo = nil
+			g.setNextMode(GameModeTitle)
+		}
+		return
+	}
+
+	if g.input.IsJustTouched() && g.isSettingsButtonTouched() {
+		g.setNextMode(GameModeSettings)
+	} else if g.input.IsJustTouched() && g.isAchievementsButtonTouched() {
+		g.setNextMode(GameModeAchievements)
+	} else if g.input.IsJustTouched() && g.isDailyButtonTouched() {
+		g.startDailyChallenge()
+	} else if g.input.IsJustTouched() && g.isDifficultySelectorTouched() {
+		g.changeDifficulty()
+	} else if g.isJustTouchedOnField() {
+		g.startRound()
+	} else if g.ticksFromModeStart > demoIdleTicks {
+		g.demo = newDemo(g)
+	}
+}
+
+// startRound starts the round of the play.
+func (g *Game) startRound() {
+	g.setNextMode(GameModePlaying)
+
+	g.sendLog(&telemetry.GameStart{
+		SightMode: string(g.settings.SightMode),
+	})
+
+	g.sound.Play(sfx.GameStart)
+}
+
+func (g *Game) drawDemo(screen *ebiten.Image) {
+	if g.ticksFromModeStart/30%2 == 0 {
+		t := g.messages().Demo
+		text.Draw(screen, t, fontL.Face, g.screenWidth/2-textLen(t)*int(fontL.FaceOptions.Size)/2, 120, color.White)
+	}
+
+	scoreText := fmt.Sprintf("SCORE %d", g.demo.world.Score)
+	text.Draw(screen, scoreText, fontS.Face, g.screenWidth-(len(scoreText)+1)*int(fontS.FaceOptions.Size), 20, color.White)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/sprite"
+	"github.com/tsujio/game-util/drawutil"
+)
+
+const devModeCheckInterval = 30
+
+var (
+	toastColorInfo  = color.RGBA{0xa0, 0xff, 0xa0, 0xff}
+	toastColorError = color.RGBA{0xff, 0x80, 0x80, 0xff}
+)
+
+// spriteSetters replaces the images of the sprites in resources/sprites.
+var spriteSetters = map[string]func(images []*ebiten.Image){
+	"fish.txt":         func(images []*ebiten.Image) { fishImages = images },
+	"enemy-normal.txt": func(images []*ebiten.Image) { normalEnemyImages = images },
+	"enemy-dizzy.txt":  func(images []*ebiten.Image) { dizzyEnemyImages = images },
+	"enemy-shy.txt":    func(images []*ebiten.Image) { shyEnemyImages = images },
+	"leaf.txt":         func(images []*ebiten.Image) { leafImage = images[0] },
+}
+
+// DevMode watches the tuning file and the sprite files on disk, and
+// applies their changes to the running game. The files are polled since
+// they are few.
+type DevMode struct {
+	ticks      uint64
+	tuningPath string
+	spriteDir  string
+	modTimes   map[string]time.Time
+}
+
+func newDevMode(tuningPath, spriteDir string) *DevMode {
+	return &DevMode{
+		tuningPath: tuningPath,
+		spriteDir:  spriteDir,
+		modTimes:   make(map[string]time.Time),
+	}
+}
+
+// Start loads the files for the first time. The tuning file is created
+// with the defaults if it does not exist.
+func (d *DevMode) Start(g *Game) {
+	if _, err := os.Stat(d.tuningPath); os.IsNotExist(err) {
+		data, err := json.MarshalIndent(core.DefaultTuning(), "", "  ")
+		if err == nil {
+			err = os.WriteFile(d.tuningPath, data, 0644)
+		}
+		if err != nil {
+			g.showToast(fmt.Sprintf("%s: %v", d.tuningPath, err), toastColorError)
+		}
+	}
+
+	d.checkFiles(g)
+}
+
+func (d *DevMode) Update(g *Game) {
+	d.ticks++
+	if d.ticks%devModeCheckInterval == 0 {
+		d.checkFiles(g)
+	}
+}
+
+// changed reports whether the file was modified since the last check.
+func (d *DevMode) changed(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if t, ok := d.modTimes[path]; ok && !info.ModTime().After(t) {
+		return false
+	}
+	d.modTimes[path] = info.ModTime()
+	return true
+}
+
+func (d *DevMode) checkFiles(g *Game) {
+	if d.changed(d.tuningPath) {
+		if err := d.reloadTuning(g); err != nil {
+			g.showToast(fmt.Sprintf("%s: %v", filepath.Base(d.tuningPath), err), toastColorError)
+		} else {
+			g.showToast(fmt.Sprintf("Reloaded %s", filepath.Base(d.tuningPath)), toastColorInfo)
+		}
+	}
+
+	for name, set := range spriteSetters {
+		path := filepath.Join(d.spriteDir, name)
+		if !d.changed(path) {
+			continue
+		}
+		if images, err := loadSpriteImagesFromFile(path); err != nil {
+			g.showToast(fmt.Sprintf("%s: %v", name, err), toastColorError)
+		} else {
+			set(images)
+			g.showToast(fmt.Sprintf("Reloaded %s", name), toastColorInfo)
+		}
+	}
+}
+
+func (d *DevMode) reloadTuning(g *Game) error {
+	data, err := os.ReadFile(d.tuningPath)
+	if err != nil {
+		return err
+	}
+	t, err := core.ParseTuning(data)
+	if err != nil {
+		return err
+	}
+	*g.tuning = *t
+	*g.world.Tuning = *g.roundTuning()
+	return nil
+}
+
+func loadSpriteImagesFromFile(path string) ([]*ebiten.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := sprite.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return drawutil.CreatePatternImageArray(s.Frames, &drawutil.CreatePatternImageOption[rune]{
+		ColorMap: s.ColorMap(),
+		DotSize:  s.DotSize,
+	}), nil
+}
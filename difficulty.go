@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/tsujio/game-archerfish/core"
+)
+
+const difficultySelectorY = 125
+
+func (g *Game) difficultyName(d core.Difficulty) string {
+	m := g.messages()
+	switch d {
+	case core.DifficultyEasy:
+		return m.DifficultyEasy
+	case core.DifficultyHard:
+		return m.DifficultyHard
+	default:
+		return m.DifficultyNormal
+	}
+}
+
+// difficulty returns the difficulty of the play, which is the chosen one
+// but for the daily challenges, always played at the normal one.
+func (g *Game) difficulty() core.Difficulty {
+	if g.dailyDay != "" {
+		return core.DifficultyNormal
+	}
+	return g.settings.Difficulty
+}
+
+// roundTuning returns the tuning of the rounds at the difficulty of the
+// play.
+func (g *Game) roundTuning() *core.Tuning {
+	return g.tuning.WithDifficulty(g.difficulty())
+}
+
+// rankingName returns the name the scores are ranked by. Each difficulty
+// has its own ranking, and the normal one keeps the name from before the
+// difficulties were introduced. Each date of the daily challenge has its
+// own ranking too.
+func (g *Game) rankingName() string {
+	if g.dailyDay != "" {
+		return fmt.Sprintf("%s-daily-%s", gameName, g.dailyDay)
+	}
+	if g.settings.Difficulty == core.DifficultyNormal {
+		return gameName
+	}
+	return fmt.Sprintf("%s-%s", gameName, g.settings.Difficulty)
+}
+
+func (g *Game) isDifficultySelectorTouched() bool {
+	pos := g.getTouchPosition()
+	return pos.Y >= difficultySelectorY-25 && pos.Y < difficultySelectorY+10
+}
+
+// changeDifficulty selects the previous difficulty by a touch on the left
+// half of the selector, and the next one on the right half.
+func (g *Game) changeDifficulty() {
+	delta := 1
+	if g.getTouchPosition().X < g.screenWidth/2 {
+		delta = -1
+	}
+
+	i := 0
+	for j, d := range core.Difficulties {
+		if d == g.settings.Difficulty {
+			i = j
+		}
+	}
+	n := len(core.Difficulties)
+	g.settings.Difficulty = core.Difficulties[(i+delta+n)%n]
+
+	g.storeSettings()
+	*g.world.Tuning = *g.roundTuning()
+	g.setNextMode(GameModeTitle)
+}
+
+func (g *Game) drawDifficultySelector(screen *ebiten.Image) {
+	t := fmt.Sprintf("< %s >", g.difficultyName(g.settings.Difficulty))
+	text.Draw(screen, t, fontM.Face, g.screenWidth/2-textLen(t)*int(fontM.FaceOptions.Size)/2, difficultySelectorY, color.White)
+}
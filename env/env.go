@@ -0,0 +1,183 @@
+// Package env wraps the game core as an environment for reinforcement
+// learning. It runs headless and as fast as the machine allows.
+package env
+
+import (
+	"github.com/tsujio/game-archerfish/core"
+)
+
+type EnemyObservation struct {
+	Kind    string  `json:"kind"`
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Z       float64 `json:"z"`
+	VX      float64 `json:"vx"`
+	ScreenX float64 `json:"screen_x"`
+	ScreenY float64 `json:"screen_y"`
+	Ticks   uint64  `json:"ticks"`
+}
+
+type BulletObservation struct {
+	X  float64 `json:"x"`
+	Y  float64 `json:"y"`
+	Z  float64 `json:"z"`
+	VX float64 `json:"vx"`
+	VY float64 `json:"vy"`
+	VZ float64 `json:"vz"`
+}
+
+// Observation is the state of the round seen by the agent. The positions
+// are in the camera coordinates unless prefixed by screen.
+type Observation struct {
+	// Enemies holds the enemies which have not been hit
+	Enemies     []EnemyObservation  `json:"enemies"`
+	Bullets     []BulletObservation `json:"bullets"`
+	TimeLeft    uint64              `json:"time_left"`
+	Score       int                 `json:"score"`
+	Hold        bool                `json:"hold"`
+	FishScreenX float64             `json:"fish_screen_x"`
+	FishScreenY float64             `json:"fish_screen_y"`
+}
+
+// Action is what the agent does in a step. The positions are on the
+// screen. The touch, which holds the fish if it is on the fish, comes
+// before the release, which shoots toward the release point if holding.
+type Action struct {
+	Touch    bool    `json:"touch"`
+	TouchX   float64 `json:"touch_x"`
+	TouchY   float64 `json:"touch_y"`
+	Release  bool    `json:"release"`
+	ReleaseX float64 `json:"release_x"`
+	ReleaseY float64 `json:"release_y"`
+}
+
+type Options struct {
+	// Tuning defaults to core.DefaultTuning()
+	Tuning *core.Tuning
+	// ScreenWidth defaults to core.StandardScreenWidth
+	ScreenWidth int
+	// FrameSkip is the number of ticks of a step, 4 by default
+	FrameSkip int
+}
+
+// Env is an environment of the rounds, in the manner of gym.
+type Env struct {
+	tuning      *core.Tuning
+	screenWidth int
+	frameSkip   int
+	world       *core.World
+}
+
+func New(opts *Options) *Env {
+	e := &Env{
+		tuning:      core.DefaultTuning(),
+		screenWidth: core.StandardScreenWidth,
+		frameSkip:   4,
+	}
+	if opts != nil {
+		if opts.Tuning != nil {
+			e.tuning = opts.Tuning
+		}
+		if opts.ScreenWidth > 0 {
+			e.screenWidth = opts.ScreenWidth
+		}
+		if opts.FrameSkip > 0 {
+			e.frameSkip = opts.FrameSkip
+		}
+	}
+	return e
+}
+
+// Reset starts a new round by the seed. The countdown is skipped so that
+// the first step can already shoot.
+func (e *Env) Reset(seed int64) Observation {
+	e.world = core.NewWorld(seed, e.tuning, e.screenWidth)
+	for e.world.TimeInTicks == 0 {
+		e.world.Update(core.Action{})
+	}
+	return e.observe()
+}
+
+// Step applies the action and advances the round by the frame skip. The
+// reward is the score gained in the step. It panics if Reset has not been
+// called.
+func (e *Env) Step(a Action) (Observation, float64, bool) {
+	score := e.world.Score
+
+	actions := []core.Action{}
+	if a.Touch {
+		actions = append(actions, core.Action{
+			Touch:  true,
+			TouchX: a.TouchX,
+			TouchY: a.TouchY,
+		})
+	}
+	if a.Release {
+		actions = append(actions, core.Action{
+			Release:  true,
+			ReleaseX: a.ReleaseX,
+			ReleaseY: a.ReleaseY,
+		})
+	}
+
+	for i := 0; i < e.frameSkip || i < len(actions); i++ {
+		if e.world.Finished() {
+			break
+		}
+		var action core.Action
+		if i < len(actions) {
+			action = actions[i]
+		}
+		if action.Release {
+			action.ReleaseX, action.ReleaseY = e.world.ClampHoldPosition(action.ReleaseX, action.ReleaseY)
+		}
+		e.world.Update(action)
+	}
+
+	return e.observe(), float64(e.world.Score - score), e.world.Finished()
+}
+
+func (e *Env) observe() Observation {
+	w := e.world
+
+	o := Observation{
+		Enemies:  []EnemyObservation{},
+		Bullets:  []BulletObservation{},
+		TimeLeft: core.FinishTimeInTicks - w.TimeInTicks,
+		Score:    w.Score,
+		Hold:     w.Hold,
+	}
+	o.FishScreenX, o.FishScreenY = w.FishScreenPosition()
+
+	for i := range w.Enemies {
+		en := &w.Enemies[i]
+		if en.Hit {
+			continue
+		}
+		x, y := w.Projection.ToScreenPosition(en.X, en.Y, en.Z)
+		o.Enemies = append(o.Enemies, EnemyObservation{
+			Kind:    en.Kind.String(),
+			X:       en.X,
+			Y:       en.Y,
+			Z:       en.Z,
+			VX:      en.VX,
+			ScreenX: x,
+			ScreenY: y,
+			Ticks:   en.Ticks,
+		})
+	}
+
+	for i := range w.Bullets {
+		b := &w.Bullets[i]
+		o.Bullets = append(o.Bullets, BulletObservation{
+			X:  b.X,
+			Y:  b.Y,
+			Z:  b.Z,
+			VX: b.VX,
+			VY: b.VY,
+			VZ: b.VZ,
+		})
+	}
+
+	return o
+}
@@ -0,0 +1,67 @@
+package main
+
+import "github.com/tsujio/game-archerfish/core"
+
+type GameEventKind int
+
+const (
+	// GameEventHit is published when a bullet hits an enemy
+	GameEventHit GameEventKind = iota
+	// GameEventMiss is published when a bullet falls into the water
+	GameEventMiss
+	// GameEventKill is published when a hit enemy falls into the water
+	GameEventKill
+	// GameEventGameOver is published when a round finishes
+	GameEventGameOver
+)
+
+// GameEvent is what happened in a round, published from the game loop.
+// EnemyKind is set for the hit and kill events, and Score is the score of
+// the round so far. DailyDay is the date of the daily challenge for the
+// game over of one.
+type GameEvent struct {
+	Kind      GameEventKind
+	EnemyKind core.EnemyKind
+	Ticks     uint64
+	Score     int
+	DailyDay  string
+}
+
+// EventBus delivers the game events to the subscribers, in the order they
+// subscribed.
+type EventBus struct {
+	handlers []func(GameEvent)
+}
+
+func (b *EventBus) Subscribe(handler func(GameEvent)) {
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *EventBus) Publish(e GameEvent) {
+	for _, h := range b.handlers {
+		h(e)
+	}
+}
+
+// publishWorldEvents publishes the game events of what happened in the
+// last update of the world.
+func (g *Game) publishWorldEvents() {
+	for _, e := range g.world.Events {
+		ge := GameEvent{
+			EnemyKind: e.Enemy.Kind,
+			Ticks:     g.world.TimeInTicks,
+			Score:     g.world.Score,
+		}
+		switch e.Kind {
+		case core.EventHit:
+			ge.Kind = GameEventHit
+		case core.EventMiss:
+			ge.Kind = GameEventMiss
+		case core.EventSplash:
+			ge.Kind = GameEventKill
+		default:
+			continue
+		}
+		g.events.Publish(ge)
+	}
+}
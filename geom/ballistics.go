@@ -0,0 +1,105 @@
+package geom
+
+import "math"
+
+// The bullets move in ticks. In each tick, the gravity is added to the
+// velocity first, and then the velocity to the position. Hence after n
+// ticks the bullet shot from p with v is at
+//
+//	p + v*n + (0, g*n*(n+1)/2, 0)
+
+// Shooter converts the pull of the fish on the screen into the velocity of
+// the bullet. The speed on the xy plane is Speed when the fish is pulled
+// by FullPull, and proportional to the pull. The speed along z is always
+// VZ.
+type Shooter struct {
+	Speed    float64
+	FullPull float64
+	VZ       float64
+}
+
+// Velocity returns the velocity of the bullet shot by pulling the fish at
+// fish to hold. The bullet flies to the opposite of the pull.
+func (s *Shooter) Velocity(fish, hold Vec2) Vec3 {
+	d := fish.Sub(hold)
+	if d.Len() == 0 {
+		return Vec3{Z: s.VZ}
+	}
+	v := d.Scale(s.Speed / s.FullPull)
+	return Vec3{v.X, v.Y, s.VZ}
+}
+
+// HoldFor is the inverse of Velocity on the xy plane. It returns where to
+// pull the fish to for the velocity.
+func (s *Shooter) HoldFor(fish Vec2, v Vec3) Vec2 {
+	return fish.Sub(Vec2{v.X, v.Y}.Scale(s.FullPull / s.Speed))
+}
+
+// PositionAfter returns the position of the bullet after the ticks, which
+// may be fractional.
+func PositionAfter(p, v Vec3, gravity, ticks float64) Vec3 {
+	q := p.Add(v.Scale(ticks))
+	q.Y += gravity * ticks * (ticks + 1) / 2
+	return q
+}
+
+// VelocityFor returns the velocity with which the bullet shot from p is
+// at target after the ticks.
+func VelocityFor(p, target Vec3, gravity, ticks float64) Vec3 {
+	d := target.Sub(p)
+	d.Y -= gravity * ticks * (ticks + 1) / 2
+	return d.Scale(1 / ticks)
+}
+
+// TicksToDepth returns the ticks for the bullet shot from p with v to reach
+// the depth z. It is not ok when the bullet never gets there.
+func TicksToDepth(p, v Vec3, z float64) (float64, bool) {
+	if v.Z == 0 {
+		return 0, false
+	}
+	t := (z - p.Z) / v.Z
+	return t, t >= 0
+}
+
+// TicksToSurface returns the ticks for the bullet shot from p with v to
+// fall to the water surface y = 0 from above. It is not ok when the
+// discriminant is negative, that is the bullet never comes down there.
+func TicksToSurface(p, v Vec3, gravity float64) (float64, bool) {
+	// g/2*t^2 + (vy+g/2)*t + y = 0
+	a := gravity / 2
+	b := v.Y + gravity/2
+	c := p.Y
+	if a == 0 {
+		if b <= 0 {
+			return 0, false
+		}
+		return -c / b, true
+	}
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return 0, false
+	}
+	t := (-b + math.Sqrt(disc)) / (2 * a)
+	return t, t >= 0
+}
+
+// Landing returns where the bullet shot from p with v reaches the depth z,
+// or where it falls into the water if earlier, and the ticks to get
+// there. It is not ok when the bullet reaches neither.
+func Landing(p, v Vec3, gravity, z float64) (Vec3, float64, bool) {
+	t, ok := TicksToDepth(p, v, z)
+	if ok {
+		if q := PositionAfter(p, v, gravity, t); q.Y <= 0 {
+			q.Z = z
+			return q, t, true
+		}
+	}
+
+	t, ok = TicksToSurface(p, v, gravity)
+	if !ok {
+		return Vec3{}, 0, false
+	}
+	q := PositionAfter(p, v, gravity, t)
+	q.Y = 0
+	return q, t, true
+}
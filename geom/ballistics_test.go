@@ -0,0 +1,126 @@
+package geom_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/geom"
+)
+
+func finite(v geom.Vec3) bool {
+	for _, c := range []float64{v.X, v.Y, v.Z} {
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestLandingAgainstBulletUpdate checks Landing with the bullets of the
+// game, stepped by their update until they fall into the water or reach
+// the depth.
+func TestLandingAgainstBulletUpdate(t *testing.T) {
+	tuning := core.DefaultTuning()
+	const depth = core.EnemyZ
+
+	for _, v := range []geom.Vec3{
+		{X: 0, Y: -10, Z: 3},
+		{X: 4, Y: -20, Z: 3},
+		{X: -7.5, Y: -35, Z: 3},
+		{X: 1, Y: -2, Z: 3},
+		{X: 0, Y: 0, Z: 3},
+		{X: 2, Y: -25, Z: 0.5},
+		{X: 0, Y: -40, Z: 6},
+	} {
+		p := geom.Vec3{X: core.FishPosXInCamera, Y: core.FishPosYInCamera, Z: core.FishPosZInCamera}
+		landing, ticks, ok := geom.Landing(p, v, tuning.Gravity, depth)
+		if !ok {
+			t.Errorf("v %+v: no landing", v)
+			continue
+		}
+
+		b := core.Bullet{X: p.X, Y: p.Y, Z: p.Z, VX: v.X, VY: v.Y, VZ: v.Z}
+		for b.Y <= 0 && b.Z < depth {
+			b.Update(tuning)
+
+			// The discrete form holds at every tick
+			q := geom.PositionAfter(p, v, tuning.Gravity, float64(b.Ticks))
+			if !near(q.X, b.X) || !near(q.Y, b.Y) || !near(q.Z, b.Z) {
+				t.Fatalf("v %+v: the bullet is at (%v, %v, %v) after %d ticks, PositionAfter gives %+v", v, b.X, b.Y, b.Z, b.Ticks, q)
+			}
+		}
+
+		// The landing is between the last tick on or above the surface and
+		// before the depth, and the first one past them. The bullets on the
+		// surface are still in the air
+		if ticks < float64(b.Ticks-1) || ticks > float64(b.Ticks) {
+			t.Errorf("v %+v: lands after %v ticks, but the bullet gets there at %d", v, ticks, b.Ticks)
+		}
+		if b.Z >= depth && b.Y <= 0 {
+			if landing.Z != depth || landing.Y > 0 {
+				t.Errorf("v %+v: lands at %+v, but the bullet reaches the depth above the water", v, landing)
+			}
+		} else if landing.Y != 0 || landing.Z > depth {
+			t.Errorf("v %+v: lands at %+v, but the bullet falls into the water before the depth", v, landing)
+		}
+		if q := geom.PositionAfter(p, v, tuning.Gravity, ticks); !near(q.X, landing.X) || !near(q.Z, landing.Z) {
+			t.Errorf("v %+v: lands at %+v, off the path at %+v", v, landing, q)
+		}
+	}
+}
+
+func TestShooterVelocityOnTheFish(t *testing.T) {
+	s := geom.Shooter{Speed: 40, FullPull: 120, VZ: 3}
+	fish := geom.Vec2{X: 320, Y: 360}
+
+	for _, hold := range []geom.Vec2{
+		fish,
+		{X: fish.X + 1e-300, Y: fish.Y},
+		{X: fish.X, Y: math.Nextafter(fish.Y, math.Inf(1))},
+	} {
+		v := s.Velocity(fish, hold)
+		if !finite(v) {
+			t.Errorf("Velocity with the hold at %+v: %+v", hold, v)
+		}
+		if v.Z != s.VZ {
+			t.Errorf("Velocity with the hold at %+v: vz %v, want %v", hold, v.Z, s.VZ)
+		}
+	}
+
+	if v := s.Velocity(fish, fish); v.X != 0 || v.Y != 0 {
+		t.Errorf("Velocity with the hold on the fish: %+v, want a straight shot", v)
+	}
+}
+
+func TestTicksToSurfaceNegativeDiscriminant(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		p, v    geom.Vec3
+		gravity float64
+	}{
+		// Under the water and sinking, it never comes up to the surface
+		{"sinking", geom.Vec3{Y: 10}, geom.Vec3{Y: 1, Z: 3}, 0.5},
+		// Pulled upward above the water, it never comes down
+		{"rising", geom.Vec3{Y: -10}, geom.Vec3{Y: -1, Z: 3}, -0.5},
+	} {
+		a, b := c.gravity/2, c.v.Y+c.gravity/2
+		if disc := b*b - 4*a*c.p.Y; disc >= 0 {
+			t.Fatalf("%s: the discriminant %v is not negative", c.name, disc)
+		}
+
+		ticks, ok := geom.TicksToSurface(c.p, c.v, c.gravity)
+		if ok {
+			t.Errorf("%s: TicksToSurface = %v, true", c.name, ticks)
+		}
+		if math.IsNaN(ticks) {
+			t.Errorf("%s: TicksToSurface = NaN", c.name)
+		}
+
+		// Nor does it land, as it never reaches the depth either
+		c.v.Z = 0
+		if q, ticks, ok := geom.Landing(c.p, c.v, c.gravity, core.EnemyZ); ok || !finite(q) || math.IsNaN(ticks) {
+			t.Errorf("%s: Landing = %+v, %v, %v", c.name, q, ticks, ok)
+		}
+	}
+}
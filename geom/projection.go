@@ -0,0 +1,49 @@
+package geom
+
+// Projection is a one-point perspective from Eye looking along the z axis.
+// The horizon passes through Center on the screen.
+type Projection struct {
+	Eye    Vec3
+	F      float64
+	Center Vec2
+}
+
+// ToScreen returns the position on the screen the position is projected
+// on. The operations are in the order of the fixed camera the game had
+// first, so that the default pose gives the very same positions.
+func (p *Projection) ToScreen(v Vec3) Vec2 {
+	z := v.Z - p.Eye.Z
+	return Vec2{
+		X: (v.X-p.Eye.X)*p.F/z + p.Center.X,
+		Y: (v.Y-p.Eye.Y)*p.F/z + p.Center.Y,
+	}
+}
+
+// ToCamera returns the position at the depth z which is projected on the
+// position on the screen.
+func (p *Projection) ToCamera(s Vec2, z float64) Vec3 {
+	d := z - p.Eye.Z
+	return Vec3{
+		X: (s.X-p.Center.X)*d/p.F + p.Eye.X,
+		Y: (s.Y-p.Center.Y)*d/p.F + p.Eye.Y,
+		Z: z,
+	}
+}
+
+// Scale returns the ratio of a length on the screen to the same length at
+// the depth z.
+func (p *Projection) Scale(z float64) float64 {
+	return p.F / (z - p.Eye.Z)
+}
+
+// ToScreenPosition is ToScreen for the separate coordinates.
+func (p *Projection) ToScreenPosition(xInCamera, yInCamera, zInCamera float64) (float64, float64) {
+	s := p.ToScreen(Vec3{xInCamera, yInCamera, zInCamera})
+	return s.X, s.Y
+}
+
+// ToCameraPosition is ToCamera for the separate coordinates.
+func (p *Projection) ToCameraPosition(xInScreen, yInScreen, zInCamera float64) (float64, float64) {
+	v := p.ToCamera(Vec2{xInScreen, yInScreen}, zInCamera)
+	return v.X, v.Y
+}
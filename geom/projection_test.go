@@ -0,0 +1,57 @@
+package geom_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tsujio/game-archerfish/geom"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func TestProjectionRoundTrip(t *testing.T) {
+	for _, p := range []geom.Projection{
+		{Eye: geom.Vec3{Y: -120}, F: 50, Center: geom.Vec2{X: 320, Y: 240}},
+		{Eye: geom.Vec3{Y: -120}, F: 50, Center: geom.Vec2{X: 427, Y: 240}},
+		// Panned, zoomed and shaken
+		{Eye: geom.Vec3{X: 30, Y: -160, Z: -40}, F: 75, Center: geom.Vec2{X: 323.5, Y: 236.2}},
+	} {
+		for _, z := range []float64{50, 125, 200, 333} {
+			for x := -1500.0; x <= 1500; x += 125 {
+				for y := -400.0; y <= 100; y += 25 {
+					sx, sy := p.ToScreenPosition(x, y, z)
+					gotX, gotY := p.ToCameraPosition(sx, sy, z)
+					if !near(gotX, x) || !near(gotY, y) {
+						t.Errorf("%+v: (%v, %v, %v) round-trips to (%v, %v)", p, x, y, z, gotX, gotY)
+					}
+				}
+			}
+			for sx := 0.0; sx <= 854; sx += 61 {
+				for sy := 0.0; sy <= 480; sy += 48 {
+					v := p.ToCamera(geom.Vec2{X: sx, Y: sy}, z)
+					if v.Z != z {
+						t.Errorf("%+v: ToCamera at the depth %v gives %v", p, z, v.Z)
+					}
+					s := p.ToScreen(v)
+					if !near(s.X, sx) || !near(s.Y, sy) {
+						t.Errorf("%+v: (%v, %v) at %v round-trips to (%v, %v)", p, sx, sy, z, s.X, s.Y)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestProjectionScale(t *testing.T) {
+	p := geom.Projection{Eye: geom.Vec3{Y: -120, Z: -50}, F: 50, Center: geom.Vec2{X: 320, Y: 240}}
+	ax, _ := p.ToScreenPosition(0, 0, 150)
+	bx, _ := p.ToScreenPosition(10, 0, 150)
+	if got, want := bx-ax, 10*p.Scale(150); !near(got, want) {
+		t.Errorf("10 at the depth 150 is %v on the screen, want %v", got, want)
+	}
+	if s := p.Scale(150); !near(s, 0.25) {
+		t.Errorf("Scale(150) = %v, want 0.25", s)
+	}
+}
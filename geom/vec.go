@@ -0,0 +1,48 @@
+// Package geom implements the projection of the stage onto the screen and
+// the ballistics of the bullets as pure functions.
+package geom
+
+import "math"
+
+// Vec2 is a position or a displacement on the screen.
+type Vec2 struct {
+	X, Y float64
+}
+
+func (v Vec2) Add(u Vec2) Vec2 {
+	return Vec2{v.X + u.X, v.Y + u.Y}
+}
+
+func (v Vec2) Sub(u Vec2) Vec2 {
+	return Vec2{v.X - u.X, v.Y - u.Y}
+}
+
+func (v Vec2) Scale(k float64) Vec2 {
+	return Vec2{v.X * k, v.Y * k}
+}
+
+func (v Vec2) Len() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Vec3 is a position or a displacement in the camera coordinates, where y
+// points down and z points away from the camera.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+func (v Vec3) Add(u Vec3) Vec3 {
+	return Vec3{v.X + u.X, v.Y + u.Y, v.Z + u.Z}
+}
+
+func (v Vec3) Sub(u Vec3) Vec3 {
+	return Vec3{v.X - u.X, v.Y - u.Y, v.Z - u.Z}
+}
+
+func (v Vec3) Scale(k float64) Vec3 {
+	return Vec3{v.X * k, v.Y * k, v.Z * k}
+}
+
+func (v Vec3) Len() float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y + v.Z*v.Z)
+}
@@ -0,0 +1,300 @@
+//go:build golden && !js
+
+// The golden test renders the game, which needs a display and a GPU. It is
+// built only with the golden tag, so that the other tests of the package
+// run headless:
+//
+//	go test -tags golden .          compare with testdata/golden
+//	go test -tags golden . -update  overwrite testdata/golden
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tsujio/game-archerfish/bot"
+	"github.com/tsujio/game-archerfish/core"
+	logging "github.com/tsujio/game-logging-server/client"
+)
+
+var update = flag.Bool("update", false, "overwrite the golden images instead of checking them")
+
+const (
+	goldenDir  = "testdata/golden"
+	goldenSeed = 1
+	// goldenTolerance is the difference allowed in each color channel, as
+	// the GPUs differ a little in blending and filtering
+	goldenTolerance = 8
+)
+
+var errTestsDone = errors.New("tests done")
+
+// inMainLoop tells the tests run in the main loop of the game, where the
+// images can be read.
+var inMainLoop bool
+
+// testRunner runs the tests in the main loop.
+type testRunner struct {
+	m    *testing.M
+	code int
+}
+
+func (r *testRunner) Update() error {
+	inMainLoop = true
+	r.code = r.m.Run()
+	return errTestsDone
+}
+
+func (r *testRunner) Draw(screen *ebiten.Image) {
+}
+
+func (r *testRunner) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return standardScreenWidth, screenHeight
+}
+
+// hasDisplay reports whether a window can be opened, which is unknown but
+// on the X11 and Wayland systems.
+func hasDisplay() bool {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "netbsd", "openbsd":
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	default:
+		return true
+	}
+}
+
+func TestMain(m *testing.M) {
+	logging.Disable()
+
+	if !hasDisplay() {
+		os.Exit(m.Run())
+	}
+
+	// Only the golden test is run in the window, unless asked otherwise
+	flag.Parse()
+	if run := flag.Lookup("test.run"); run.Value.String() == "" {
+		run.Value.Set("^TestGolden$")
+	}
+
+	r := &testRunner{m: m}
+	ebiten.SetWindowSize(standardScreenWidth, screenHeight)
+	ebiten.SetWindowTitle("Archerfish tests")
+	if err := ebiten.RunGame(r); err != nil && err != errTestsDone {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(r.code)
+}
+
+// goldenScene is a state of the game whose rendering is compared with the
+// golden image. The states are reached by playing with the bot from the
+// fixed seed.
+type goldenScene struct {
+	name  string
+	setup func(g *Game)
+}
+
+var goldenRanking = []logging.GameScore{
+	{GameName: gameName, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), PlayerID: "golden", PlayID: "1", Score: 120},
+	{GameName: gameName, Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), PlayerID: "other", PlayID: "2", Score: 80},
+	{GameName: gameName, Timestamp: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), PlayerID: "golden", PlayID: "3", Score: 40},
+}
+
+func runGoldenTicks(g *Game, ticks int) {
+	for i := 0; i < ticks; i++ {
+		g.Update()
+	}
+}
+
+func setupGoldenGameOver(g *Game) {
+	g.setNextMode(GameModePlaying)
+	for g.mode == GameModePlaying {
+		g.Update()
+	}
+	// Wait for the camera panning, before the bot taps the screen
+	runGoldenTicks(g, 100)
+}
+
+var goldenScenes = []goldenScene{
+	{
+		name:  "title",
+		setup: func(g *Game) {},
+	},
+	{
+		name: "countdown",
+		setup: func(g *Game) {
+			g.setNextMode(GameModePlaying)
+			runGoldenTicks(g, 60)
+		},
+	},
+	{
+		name: "playing-sight",
+		setup: func(g *Game) {
+			g.setNextMode(GameModePlaying)
+			runGoldenTicks(g, core.CountdownTicks+600)
+			for !g.world.Hold && g.mode == GameModePlaying {
+				g.Update()
+			}
+			runGoldenTicks(g, 5)
+		},
+	},
+	{
+		name:  "game-over",
+		setup: setupGoldenGameOver,
+	},
+	{
+		name: "results",
+		setup: func(g *Game) {
+			setupGoldenGameOver(g)
+			g.setNextMode(GameModeResults)
+			runGoldenTicks(g, 30)
+		},
+	},
+	{
+		name: "ranking",
+		setup: func(g *Game) {
+			setupGoldenGameOver(g)
+			g.ranking = goldenRanking
+			g.setNextMode(GameModeRanking)
+			runGoldenTicks(g, 30)
+		},
+	},
+}
+
+var goldenSound = newSoundManager(audioContext)
+
+func newGoldenGame() *Game {
+	settings := defaultSettings()
+	settings.Mute = true
+	viewport := newViewport(settings.ScalingMode)
+
+	g := &Game{
+		playerID:        "golden",
+		settings:        settings,
+		tuning:          core.DefaultTuning(),
+		sound:           goldenSound,
+		viewport:        viewport,
+		fixedRandomSeed: goldenSeed,
+		input:           newBotInput(bot.LevelHard, goldenSeed),
+	}
+	g.achievements = newAchievements(g.playerID, false)
+	g.achievements.Subscribe(&g.events, g.showAchievementToast)
+	g.applySettings()
+	g.initialize()
+	return g
+}
+
+func renderGoldenScene(scene goldenScene) *image.RGBA {
+	g := newGoldenGame()
+	scene.setup(g)
+
+	screen := ebiten.NewImage(g.screenWidth, screenHeight)
+	defer screen.Dispose()
+	// The golden images are in logical pixels whatever the device scale
+	// factor is
+	g.viewport.scale, g.viewport.offsetX, g.viewport.offsetY = 1, 0, 0
+	g.Draw(screen)
+
+	img := image.NewRGBA(image.Rect(0, 0, g.screenWidth, screenHeight))
+	screen.ReadPixels(img.Pix)
+	return img
+}
+
+// TestGolden compares the rendering of the game modes with the PNG files
+// in testdata/golden. Run with -update to overwrite the files.
+func TestGolden(t *testing.T) {
+	if !inMainLoop {
+		t.Skip("no display to render on")
+	}
+
+	for _, scene := range goldenScenes {
+		scene := scene
+		t.Run(scene.name, func(t *testing.T) {
+			actual := renderGoldenScene(scene)
+			path := filepath.Join(goldenDir, scene.name+".png")
+
+			if *update {
+				if err := writePNG(path, actual); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			defer f.Close()
+			golden, err := png.Decode(f)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+
+			if diff := diffImages(golden, actual, goldenTolerance); diff != "" {
+				actualPath := filepath.Join(goldenDir, scene.name+".actual.png")
+				if err := writePNG(actualPath, actual); err != nil {
+					t.Fatal(err)
+				}
+				t.Errorf("%s (see %s)", diff, actualPath)
+			}
+		})
+	}
+}
+
+// diffImages describes how the images differ, or returns an empty string
+// if no channel of any pixel differs by more than the tolerance.
+func diffImages(expected, actual image.Image, tolerance int) string {
+	if expected.Bounds().Size() != actual.Bounds().Size() {
+		return fmt.Sprintf("size %v, want %v", actual.Bounds().Size(), expected.Bounds().Size())
+	}
+
+	count := 0
+	var first image.Point
+	eMin, aMin := expected.Bounds().Min, actual.Bounds().Min
+	size := expected.Bounds().Size()
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			er, eg, eb, ea := expected.At(eMin.X+x, eMin.Y+y).RGBA()
+			ar, ag, ab, aa := actual.At(aMin.X+x, aMin.Y+y).RGBA()
+			for _, d := range [4][2]uint32{{er, ar}, {eg, ag}, {eb, ab}, {ea, aa}} {
+				delta := int(d[0]>>8) - int(d[1]>>8)
+				if delta < -tolerance || delta > tolerance {
+					if count == 0 {
+						first = image.Pt(x, y)
+					}
+					count++
+					break
+				}
+			}
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d pixels differ, first at %v", count, first)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
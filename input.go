@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/tsujio/game-archerfish/bot"
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/telemetry"
+	"github.com/tsujio/game-util/touchutil"
+)
+
+// Input is the source of the touches which operate the game. The position
+// is on the logical screen.
+type Input interface {
+	Update(g *Game)
+	IsJustTouched() bool
+	IsBeingTouched() bool
+	IsJustReleased() bool
+	GetTouchPosition() touchutil.TouchPosition
+}
+
+// touchInput reads the mouse or the touch screen of the player.
+type touchInput struct {
+	context  *touchutil.TouchContext
+	viewport *Viewport
+}
+
+func newTouchInput(viewport *Viewport) *touchInput {
+	return &touchInput{
+		context:  touchutil.CreateTouchContext(),
+		viewport: viewport,
+	}
+}
+
+func (i *touchInput) Update(g *Game) {
+	i.context.Update()
+}
+
+func (i *touchInput) IsJustTouched() bool {
+	return i.context.IsJustTouched()
+}
+
+func (i *touchInput) IsBeingTouched() bool {
+	return i.context.IsBeingTouched()
+}
+
+func (i *touchInput) IsJustReleased() bool {
+	return i.context.IsJustReleased()
+}
+
+func (i *touchInput) GetTouchPosition() touchutil.TouchPosition {
+	return i.viewport.ToLogicalPosition(i.context.GetTouchPosition())
+}
+
+// Replay is a play recorded by the logs.
+type Replay struct {
+	Seed          int64
+	Rules         int
+	Difficulty    core.Difficulty
+	ScreenWidth   int
+	ControlScheme ControlScheme
+	Touches       []telemetry.TouchRecord
+}
+
+// Settings returns a copy of the settings with the ones the play was
+// recorded with, by which the touches act the same.
+func (r *Replay) Settings(s *Settings) *Settings {
+	c := *s
+	c.Difficulty = r.Difficulty
+	c.ControlScheme = r.ControlScheme
+	c.Widescreen = r.ScreenWidth == wideScreenWidth
+	return &c
+}
+
+// readReplay reads the events of sendLog in NDJSON. The seed, the rules
+// and the settings are taken from the first session init, and the touches
+// from the touch batches in order. The logs of other rules or without the
+// settings are refused, as they cannot be played the same. The events
+// unknown to this version are skipped.
+func readReplay(r io.Reader) (*Replay, error) {
+	replay := &Replay{}
+	seedFound := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		e, err := telemetry.Decode(scanner.Bytes())
+		if errors.Is(err, telemetry.ErrUnknownAction) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch e := e.(type) {
+		case *telemetry.SessionInit:
+			if !seedFound {
+				replay.Seed = e.Seed
+				replay.Rules = e.Rules
+				replay.Difficulty = telemetry.HeaderOf(e).Difficulty
+				replay.ScreenWidth = e.ScreenWidth
+				replay.ControlScheme = ControlScheme(e.ControlScheme)
+				seedFound = true
+			}
+		case *telemetry.TouchBatch:
+			replay.Touches = append(replay.Touches, e.Touches...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !seedFound {
+		return nil, fmt.Errorf("no initialize action with seed")
+	}
+
+	if replay.Rules != core.RulesVersion {
+		return nil, fmt.Errorf("played by the rules of version %d, which are not of this version %d", replay.Rules, core.RulesVersion)
+	}
+	if _, ok := core.ParseDifficulty(string(replay.Difficulty)); !ok {
+		return nil, fmt.Errorf("unknown difficulty %q", replay.Difficulty)
+	}
+	if replay.ScreenWidth != standardScreenWidth && replay.ScreenWidth != wideScreenWidth {
+		return nil, fmt.Errorf("invalid screen width %d", replay.ScreenWidth)
+	}
+	if replay.ControlScheme != ControlSchemePull && replay.ControlScheme != ControlSchemePush {
+		return nil, fmt.Errorf("invalid control scheme %q", replay.ControlScheme)
+	}
+
+	return replay, nil
+}
+
+// replayInput plays the recorded touches back. A record is played when
+// the ticks from the mode start reach the ones of the record, and the mode
+// is the one of the record if it is tagged, which holds as long as the
+// game runs with the same seed.
+type replayInput struct {
+	touches []telemetry.TouchRecord
+	next    int
+	current *telemetry.TouchRecord
+	last    telemetry.TouchRecord
+}
+
+func newReplayInput(replay *Replay) *replayInput {
+	return &replayInput{
+		touches: replay.Touches,
+	}
+}
+
+func (i *replayInput) Update(g *Game) {
+	i.current = nil
+	if i.next < len(i.touches) && i.touches[i.next].Ticks == g.ticksFromModeStart &&
+		(i.touches[i.next].Mode == "" || i.touches[i.next].Mode == g.mode.String()) {
+		i.current = &i.touches[i.next]
+		i.last = *i.current
+		i.next++
+	}
+}
+
+func (i *replayInput) IsJustTouched() bool {
+	return i.current != nil && i.current.JustTouched
+}
+
+func (i *replayInput) IsBeingTouched() bool {
+	return i.current != nil && !i.current.JustReleased
+}
+
+func (i *replayInput) IsJustReleased() bool {
+	return i.current != nil && i.current.JustReleased
+}
+
+func (i *replayInput) GetTouchPosition() touchutil.TouchPosition {
+	return touchutil.TouchPosition{X: i.last.X, Y: i.last.Y}
+}
+
+// botInput lets the bot play the rounds. Out of them, it taps the field a
+// while after the screen shows up to go on.
+type botInput struct {
+	bot                       *bot.Bot
+	touching                  bool
+	justTouched, justReleased bool
+	pos                       touchutil.TouchPosition
+}
+
+const botTapTicks = 120
+
+func newBotInput(level bot.Level, seed int64) *botInput {
+	return &botInput{
+		bot: bot.New(level, seed),
+	}
+}
+
+func (i *botInput) Update(g *Game) {
+	i.justTouched, i.justReleased = false, false
+
+	if g.mode != GameModePlaying {
+		if i.touching {
+			i.touching = false
+			i.justReleased = true
+		} else if g.mode != GameModeSettings && g.mode != GameModeAchievements && g.ticksFromModeStart == botTapTicks {
+			i.touching = true
+			i.justTouched = true
+			i.pos = touchutil.TouchPosition{X: g.screenWidth / 2, Y: screenHeight / 2}
+		}
+		return
+	}
+
+	action := i.bot.Act(g.world)
+	switch {
+	case action.Touch:
+		i.touching = true
+		i.justTouched = true
+		i.setPosition(g, action.TouchX, action.TouchY)
+	case action.Release:
+		i.touching = false
+		i.justReleased = true
+		i.setPosition(g, action.ReleaseX, action.ReleaseY)
+	case i.bot.Holding():
+		x, y := i.bot.HoldPosition()
+		i.setPosition(g, x, y)
+	}
+}
+
+// setPosition moves the touch to where getHoldPosition returns the hold
+// position.
+func (i *botInput) setPosition(g *Game, x, y float64) {
+	if g.settings.ControlScheme == ControlSchemePush {
+		fishX, fishY := g.world.FishScreenPosition()
+		x, y = fishX*2-x, fishY*2-y
+	}
+	i.pos = touchutil.TouchPosition{X: int(math.Round(x)), Y: int(math.Round(y))}
+}
+
+func (i *botInput) IsJustTouched() bool {
+	return i.justTouched
+}
+
+func (i *botInput) IsBeingTouched() bool {
+	return i.touching
+}
+
+func (i *botInput) IsJustReleased() bool {
+	return i.justReleased
+}
+
+func (i *botInput) GetTouchPosition() touchutil.TouchPosition {
+	return i.pos
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tsujio/game-archerfish/core"
+)
+
+func TestReadReplaySettings(t *testing.T) {
+	replay, err := readReplay(strings.NewReader(`{"version":1,"player_id":"p","play_id":"a","difficulty":"hard","action":"initialize","rules":1,"seed":7,"screen_width":854,"control_scheme":"push"}
+{"version":1,"player_id":"p","play_id":"b","difficulty":"easy","action":"initialize","rules":1,"seed":8,"screen_width":640,"control_scheme":"pull"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Seed != 7 || replay.Difficulty != core.DifficultyHard || replay.ScreenWidth != wideScreenWidth || replay.ControlScheme != ControlSchemePush {
+		t.Errorf("read %+v, want the first play", *replay)
+	}
+
+	for _, log := range []string{
+		`{"player_id":"p","play_id":"a","action":"initialize","seed":7}`,
+		`{"version":1,"difficulty":"normal","action":"initialize","seed":7,"screen_width":640,"control_scheme":"pull"}`,
+		`{"version":1,"difficulty":"normal","action":"initialize","rules":2,"seed":7,"screen_width":640,"control_scheme":"pull"}`,
+		`{"version":1,"difficulty":"normal","action":"initialize","rules":1,"seed":7}`,
+		`{"version":1,"difficulty":"nightmare","action":"initialize","rules":1,"seed":7,"screen_width":640,"control_scheme":"pull"}`,
+		`{"version":1,"difficulty":"normal","action":"initialize","rules":1,"seed":7,"screen_width":800,"control_scheme":"pull"}`,
+		`{"version":1,"difficulty":"normal","action":"initialize","rules":1,"seed":7,"screen_width":640,"control_scheme":"swipe"}`,
+	} {
+		if _, err := readReplay(strings.NewReader(log)); err == nil {
+			t.Errorf("a replay is read from %s", log)
+		}
+	}
+}
+
+func TestReplaySettings(t *testing.T) {
+	saved := defaultSettings()
+	replay := &Replay{
+		Difficulty:    core.DifficultyHard,
+		ScreenWidth:   wideScreenWidth,
+		ControlScheme: ControlSchemePush,
+	}
+
+	s := replay.Settings(saved)
+	if s.Difficulty != core.DifficultyHard || !s.Widescreen || s.ControlScheme != ControlSchemePush {
+		t.Errorf("the replay is played by %+v", *s)
+	}
+	if *saved != *defaultSettings() {
+		t.Errorf("the saved settings are changed to %+v", *saved)
+	}
+}
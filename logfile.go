@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/tsujio/game-archerfish/telemetry"
+)
+
+// LogFile is a local sink of the logs, which appends the payloads of
+// sendLog in NDJSON, the format read by the replay and the touch analyzer.
+type LogFile struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+func openLogFile(path string) (*LogFile, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &LogFile{
+		file: f,
+		enc:  json.NewEncoder(f),
+	}, nil
+}
+
+func (l *LogFile) Write(e telemetry.Event) error {
+	return l.enc.Encode(e)
+}
+
+func (l *LogFile) Close() error {
+	return l.file.Close()
+}
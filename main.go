@@ -2,20 +2,26 @@ package main
 
 import (
 	"embed"
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
 	"math"
-	"math/rand"
 	"os"
-	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/tsujio/game-archerfish/bot"
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/geom"
+	"github.com/tsujio/game-archerfish/sprite"
+	"github.com/tsujio/game-archerfish/telemetry"
 	logging "github.com/tsujio/game-logging-server/client"
 	"github.com/tsujio/game-util/drawutil"
 	"github.com/tsujio/game-util/resourceutil"
@@ -24,150 +30,76 @@ import (
 
 const (
 	gameName             = "archerfish"
-	screenWidth          = 640
-	screenHeight         = 480
-	fishPosXInCamera     = 0
-	fishPosYInCamera     = 0
-	fishPosZInCamera     = cameraF
-	touchableR           = 50
-	cameraF              = 50
-	cameraHeight         = 120
-	gravity              = 0.5
-	bulletR              = 10
-	normalEnemyR         = 40
-	dizzyEnemyR          = 35
-	shyEnemyR            = 30
-	normalEnemyYInScreen = screenHeight/2 - 50.0
-	dizzyEnemyYInScreen  = normalEnemyYInScreen - 70
-	shyEnemyYInScreen    = dizzyEnemyYInScreen - 70
-	enemyZ               = 200.0
-	finishTimeInTicks    = 60 * 60
+	standardScreenWidth  = core.StandardScreenWidth
+	wideScreenWidth      = core.WideScreenWidth
+	screenHeight         = core.ScreenHeight
+	fishPosXInCamera     = core.FishPosXInCamera
+	fishPosYInCamera     = core.FishPosYInCamera
+	fishPosZInCamera     = core.FishPosZInCamera
+	cameraF              = core.CameraF
+	cameraHeight         = core.CameraHeight
+	enemyZ               = core.EnemyZ
+	finishTimeInTicks    = core.FinishTimeInTicks
+	fullscreenButtonX    = 10
+	fullscreenButtonY    = 10
+	fullscreenButtonSize = 20
 )
 
-//go:embed resources/*.ttf resources/*.dat resources/bgm-*.wav resources/secret
+//go:embed resources/*.ttf resources/*.dat resources/bgm-*.wav resources/sprites/*.txt resources/secret
 var resources embed.FS
 
 var (
 	fontL, fontM, fontS = resourceutil.ForceLoadFont(resources, "resources/PressStart2P-Regular.ttf", nil)
 	audioContext        = audio.NewContext(48000)
-	gameStartAudioData  = resourceutil.ForceLoadDecodedAudio(resources, "resources/魔王魂 効果音 システム49.mp3.dat", audioContext)
-	timeStartAudioData  = resourceutil.ForceLoadDecodedAudio(resources, "resources/魔王魂 効果音 笛01.mp3.dat", audioContext)
-	splashAudioData     = resourceutil.ForceLoadDecodedAudio(resources, "resources/魔王魂  水02.mp3.dat", audioContext)
-	shootAudioData      = resourceutil.ForceLoadDecodedAudio(resources, "resources/魔王魂 効果音 点火01.mp3.dat", audioContext)
-	hitAudioData        = resourceutil.ForceLoadDecodedAudio(resources, "resources/魔王魂 効果音 システム16.mp3.dat", audioContext)
-	gameOverAudioData   = resourceutil.ForceLoadDecodedAudio(resources, "resources/魔王魂 効果音 物音15.mp3.dat", audioContext)
-	rankingAudioData    = resourceutil.ForceLoadDecodedAudio(resources, "resources/魔王魂 効果音 システム46.mp3.dat", audioContext)
-	bgmPlayer           = resourceutil.ForceCreateBGMPlayer(resources, "resources/bgm-archerfish.wav", audioContext)
 )
 
-func toScreenPosition(xInCamera, yInCamera, zInCamera float64) (float64, float64) {
-	x := xInCamera * cameraF / zInCamera
-	y := (yInCamera + cameraHeight) * cameraF / zInCamera
-	return x + float64(screenWidth)/2, y + float64(screenHeight)/2
-}
-
-func toCameraPosition(xInScreen, yInScreen, zInCamera float64) (float64, float64) {
-	x := (xInScreen - float64(screenWidth)/2) * zInCamera / cameraF
-	y := (yInScreen-float64(screenHeight)/2)*zInCamera/cameraF - cameraHeight
-	return x, y
-}
-
-type Fish struct {
-	game    *Game
-	ticks   uint64
-	x, y, z float64
-}
-
-func (f *Fish) Update() {
-	f.ticks++
-}
-
-var fishPattern1 = [][]int{
-	{0, 0, 0, 0, 3, 0, 0, 0, 0},
-	{0, 0, 0, 3, 3, 3, 0, 0, 0},
-	{0, 0, 2, 3, 3, 3, 2, 0, 0},
-	{0, 2, 1, 2, 3, 2, 1, 2, 0},
-	{0, 2, 2, 2, 3, 2, 2, 2, 0},
-	{0, 3, 2, 3, 3, 3, 2, 3, 0},
-	{0, 3, 3, 3, 1, 3, 3, 3, 0},
-	{0, 3, 3, 1, 1, 1, 3, 3, 0},
-	{0, 3, 3, 3, 1, 3, 3, 3, 0},
-	{3, 3, 3, 1, 1, 1, 3, 3, 3},
-}
+// forceLoadSpriteImages creates the images of the frames in the sprite
+// file.
+func forceLoadSpriteImages(path string) []*ebiten.Image {
+	f, err := resources.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
 
-var fishPattern2 = [][]int{
-	{0, 0, 0, 0, 3, 0, 0, 0, 0},
-	{0, 0, 0, 3, 3, 3, 0, 0, 0},
-	{0, 0, 2, 3, 3, 3, 2, 0, 0},
-	{0, 2, 1, 2, 3, 2, 1, 2, 0},
-	{0, 2, 2, 2, 3, 2, 2, 2, 0},
-	{0, 3, 2, 3, 3, 3, 2, 3, 0},
-	{0, 3, 3, 3, 1, 3, 3, 3, 0},
-	{0, 3, 3, 1, 1, 1, 3, 3, 0},
-	{0, 3, 3, 3, 1, 3, 3, 3, 0},
-}
+	s, err := sprite.Parse(f)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", path, err))
+	}
 
-var fishPattern3 = [][]int{
-	{0, 0, 0, 0, 0, 0, 0, 0, 0},
-	{0, 0, 0, 3, 3, 3, 0, 0, 0},
-	{0, 0, 2, 3, 3, 3, 2, 0, 0},
-	{0, 2, 1, 2, 3, 2, 1, 2, 0},
-	{0, 2, 2, 2, 3, 2, 2, 2, 0},
-	{0, 3, 2, 3, 3, 3, 2, 3, 0},
-	{0, 3, 3, 3, 1, 3, 3, 3, 0},
-	{0, 3, 3, 1, 1, 1, 3, 3, 0},
-	{0, 3, 3, 3, 1, 3, 3, 3, 0},
+	return drawutil.CreatePatternImageArray(s.Frames, &drawutil.CreatePatternImageOption[rune]{
+		ColorMap: s.ColorMap(),
+		DotSize:  s.DotSize,
+	})
 }
 
-var fishImages = (func() []*ebiten.Image {
-	var images []*ebiten.Image
-	for _, p := range [][][]int{fishPattern1, fishPattern2, fishPattern3} {
-		images = append(images, drawutil.CreatePatternImage(p, &drawutil.CreatePatternImageOption[int]{
-			DotSize: 5,
-			ColorMap: map[int]color.Color{
-				1: color.Black,
-				2: color.White,
-				3: color.RGBA{0xa5, 0xf5, 0xf5, 0xff},
-			},
-		}))
-	}
-	return images
-})()
+var fishImages = forceLoadSpriteImages("resources/sprites/fish.txt")
 
-func (f *Fish) Draw(screen *ebiten.Image) {
+func drawFish(screen *ebiten.Image, f *core.Fish, hold bool, camera *Camera) {
 	var image *ebiten.Image
-	if f.game.hold {
+	if hold {
 		image = fishImages[2]
 	} else {
-		image = fishImages[(f.ticks/30)%2]
+		image = fishImages[(f.Ticks/30)%2]
 	}
 
-	x, y := toScreenPosition(f.x, f.y, f.z)
+	x, y := camera.ToScreenPosition(f.X, f.Y, f.Z)
+	scale := camera.Magnification(f.Z)
 	drawutil.DrawImage(screen, image, x, y, &drawutil.DrawImageOption{
+		Scale:        scale,
 		BasePosition: drawutil.DrawImagePositionCenter,
 	})
 }
 
-type Bullet struct {
-	ticks      uint64
-	x, y, z    float64
-	vx, vy, vz float64
-	r          float64
-}
-
-func (b *Bullet) Update() {
-	b.ticks++
-
-	b.vy += gravity
-
-	b.x += b.vx
-	b.y += b.vy
-	b.z += b.vz
+func submitFish(q *RenderQueue, f *core.Fish, hold bool, camera *Camera) {
+	q.Submit(RenderLayerWorld, f.Z, func(screen *ebiten.Image) {
+		drawFish(screen, f, hold, camera)
+	})
 }
 
-func (b *Bullet) Draw(screen *ebiten.Image) {
-	x, y := toScreenPosition(b.x, b.y, b.z)
-	ebitenutil.DrawCircle(screen, x, y, bulletR*fishPosZInCamera/b.z, color.RGBA{
+func drawBullet(screen *ebiten.Image, b *core.Bullet, camera *Camera) {
+	x, y := camera.ToScreenPosition(b.X, b.Y, b.Z)
+	ebitenutil.DrawCircle(screen, x, y, b.R*camera.Scale(b.Z), color.RGBA{
 		R: 0x40,
 		G: 0xa0,
 		B: 0xff,
@@ -175,142 +107,59 @@ func (b *Bullet) Draw(screen *ebiten.Image) {
 	})
 }
 
-type SplashEffect struct {
-	ticks   uint64
-	x, y, z float64
-	vx, vy  float64
-}
-
-func (e *SplashEffect) Update() {
-	e.ticks++
-
-	e.vy += gravity
-
-	e.x += e.vx
-	e.y += e.vy
+func submitBullet(q *RenderQueue, b *core.Bullet, camera *Camera) {
+	q.Submit(RenderLayerWorld, b.Z, func(screen *ebiten.Image) {
+		drawBullet(screen, b, camera)
+	})
 }
 
-func (e *SplashEffect) Draw(screen *ebiten.Image) {
-	if e.y < 0 {
-		x, y := toScreenPosition(e.x, e.y, e.z)
+func drawSplashEffect(screen *ebiten.Image, e *core.SplashEffect, camera *Camera) {
+	if e.Y < 0 {
+		x, y := camera.ToScreenPosition(e.X, e.Y, e.Z)
 		ebitenutil.DrawRect(screen, x, y, 3, 3, color.White)
 	}
 }
 
-type EnemyKind int
-
-const (
-	EnemyKindNormal EnemyKind = iota
-	EnemyKindDizzy
-	EnemyKindShy
-)
-
-type Enemy struct {
-	ticks       uint64
-	kind        EnemyKind
-	hit         bool
-	x, y, z     float64
-	vx, vy, vx0 float64
-	r           float64
-	game        *Game
+func submitSplashEffect(q *RenderQueue, e *core.SplashEffect, camera *Camera) {
+	q.Submit(RenderLayerWorld, e.Z, func(screen *ebiten.Image) {
+		drawSplashEffect(screen, e, camera)
+	})
 }
 
-func (e *Enemy) Update() {
-	if e.ticks == 0 {
-		e.vx0 = e.vx
-	}
-
-	e.ticks++
+var (
+	normalEnemyImages = forceLoadSpriteImages("resources/sprites/enemy-normal.txt")
+	dizzyEnemyImages  = forceLoadSpriteImages("resources/sprites/enemy-dizzy.txt")
+	shyEnemyImages    = forceLoadSpriteImages("resources/sprites/enemy-shy.txt")
+)
 
-	if e.hit {
-		e.vy += gravity
-		e.y += e.vy
-		return
+func enemyImages(kind core.EnemyKind) []*ebiten.Image {
+	switch kind {
+	case core.EnemyKindDizzy:
+		return dizzyEnemyImages
+	case core.EnemyKindShy:
+		return shyEnemyImages
+	default:
+		return normalEnemyImages
 	}
-
-	switch e.kind {
-	case EnemyKindDizzy:
-		if e.ticks%60 == 0 && e.game.random.Int()%2 == 0 {
-			if e.vx == 0 {
-				e.vx = e.vx0
-			} else {
-				e.vx = 0
-			}
-		}
-	case EnemyKindShy:
-		if e.ticks == 120 {
-			e.vx = 0
-		} else if e.ticks == 240 {
-			e.vx = e.vx0 * -1
-		}
-	}
-
-	e.x += e.vx
-}
-
-var enemyPatterns = [][][]rune{
-	{
-		[]rune(" ####  "),
-		[]rune("#.#.###"),
-		[]rune("###### "),
-		[]rune("#######"),
-		[]rune("# ##  #"),
-	},
-	{
-		[]rune(" ####  "),
-		[]rune("#######"),
-		[]rune("#.#.## "),
-		[]rune("#######"),
-		[]rune("#   # #"),
-	},
 }
 
-var normalEnemyImages = drawutil.CreatePatternImageArray(enemyPatterns, &drawutil.CreatePatternImageOption[rune]{
-	ColorMap: map[rune]color.Color{
-		'#': color.Black,
-		'.': color.White,
-	},
-})
-
-var dizzyEnemyImages = drawutil.CreatePatternImageArray(enemyPatterns, &drawutil.CreatePatternImageOption[rune]{
-	ColorMap: map[rune]color.Color{
-		'#': color.RGBA{0, 0, 0xff, 0xff},
-		'.': color.White,
-	},
-})
-
-var shyEnemyImages = drawutil.CreatePatternImageArray(enemyPatterns, &drawutil.CreatePatternImageOption[rune]{
-	ColorMap: map[rune]color.Color{
-		'#': color.RGBA{0xff, 0, 0, 0xff},
-		'.': color.White,
-	},
-})
-
-func (e *Enemy) Draw(screen *ebiten.Image) {
-	x, y := toScreenPosition(e.x, e.y, e.z)
-	xr, _ := toScreenPosition(e.x-e.r, e.y, e.z)
+func drawEnemy(screen *ebiten.Image, e *core.Enemy, camera *Camera) {
+	x, y := camera.ToScreenPosition(e.X, e.Y, e.Z)
+	xr, _ := camera.ToScreenPosition(e.X-e.R, e.Y, e.Z)
 	w, _ := normalEnemyImages[0].Size()
 
 	scaleX := math.Abs(xr-x) * 2 / float64(w)
 	scaleY := scaleX
 
-	if e.vx > 0 || e.vx == 0 && e.vx0 > 0 {
+	if e.IsFacingRight() {
 		scaleX *= -1
 	}
 
-	if e.hit {
+	if e.Hit {
 		scaleY *= -1
 	}
 
-	var image *ebiten.Image
-	switch e.kind {
-	case EnemyKindNormal:
-		image = normalEnemyImages[e.ticks/30%2]
-	case EnemyKindDizzy:
-		image = dizzyEnemyImages[e.ticks/30%2]
-	case EnemyKindShy:
-		image = shyEnemyImages[e.ticks/30%2]
-	}
+	image := enemyImages(e.Kind)[e.Ticks/30%2]
 
 	drawutil.DrawImage(screen, image, x, y, &drawutil.DrawImageOption{
 		ScaleX:       scaleX,
@@ -319,6 +168,12 @@ func (e *Enemy) Draw(screen *ebiten.Image) {
 	})
 }
 
+func submitEnemy(q *RenderQueue, e *core.Enemy, camera *Camera) {
+	q.Submit(RenderLayerWorld, e.Z, func(screen *ebiten.Image) {
+		drawEnemy(screen, e, camera)
+	})
+}
+
 type GainEffect struct {
 	ticks    uint64
 	x, y, y0 float64
@@ -338,295 +193,196 @@ func (e *GainEffect) Draw(screen *ebiten.Image) {
 	text.Draw(screen, t, fontM.Face, int(e.x), int(e.y), color.RGBA{0xff, 0xe0, 0, 0xff})
 }
 
-type Leaf struct {
-	xInScreen, yInScreen float64
-	scaleX, scaleY       float64
-	rotate               float64
+func (e *GainEffect) Submit(q *RenderQueue) {
+	q.Submit(RenderLayerOverlay, 0, e.Draw)
 }
 
-var leafImage = drawutil.CreatePatternImage([][]rune{
-	[]rune(" ## "),
-	[]rune("####"),
-	[]rune("####"),
-	[]rune("### "),
-	[]rune(" #  "),
-}, &drawutil.CreatePatternImageOption[rune]{
-	ColorMap: map[rune]color.Color{
-		'#': color.RGBA{0x2c, 0xda, 0x31, 0xff},
-	},
-	DotSize: 3,
-})
-
-func (l *Leaf) Draw(screen *ebiten.Image) {
-	drawutil.DrawImage(screen, leafImage, l.xInScreen, l.yInScreen, &drawutil.DrawImageOption{
-		ScaleX:       l.scaleX,
-		ScaleY:       l.scaleY,
-		Rotate:       l.rotate,
+var leafImage = forceLoadSpriteImages("resources/sprites/leaf.txt")[0]
+
+func drawLeaf(screen *ebiten.Image, l *core.Leaf, camera *Camera) {
+	x, y := camera.ToScreenPosition(l.X, l.Y, l.Z)
+	scale := camera.Magnification(l.Z)
+	drawutil.DrawImage(screen, leafImage, x, y, &drawutil.DrawImageOption{
+		ScaleX:       l.ScaleX * scale,
+		ScaleY:       l.ScaleY * scale,
+		Rotate:       l.Rotate,
 		BasePosition: drawutil.DrawImagePositionCenter,
 	})
 }
 
+func submitLeaf(q *RenderQueue, l *core.Leaf, camera *Camera) {
+	q.Submit(RenderLayerWorld, l.Z, func(screen *ebiten.Image) {
+		drawLeaf(screen, l, camera)
+	})
+}
+
 type GameMode int
 
 const (
 	GameModeTitle GameMode = iota
 	GameModePlaying
 	GameModeGameOver
+	GameModeResults
 	GameModeRanking
+	GameModeSettings
+	GameModeAchievements
 )
 
-type TouchRecord struct {
-	Ticks        uint64 `json:"ticks"`
-	JustTouched  bool   `json:"just_touched"`
-	JustReleased bool   `json:"just_released"`
-	X            int    `json:"x"`
-	Y            int    `json:"y"`
+func (m GameMode) String() string {
+	switch m {
+	case GameModeTitle:
+		return "title"
+	case GameModePlaying:
+		return "playing"
+	case GameModeGameOver:
+		return "game_over"
+	case GameModeResults:
+		return "results"
+	case GameModeRanking:
+		return "ranking"
+	case GameModeSettings:
+		return "settings"
+	case GameModeAchievements:
+		return "achievements"
+	default:
+		return "unknown"
+	}
 }
 
 type Game struct {
 	playerID           string
+	settings           *Settings
+	persistSettings    bool
+	tuning             *core.Tuning
+	devMode            *DevMode
+	logFile            *LogFile
+	toasts             []Toast
+	events             EventBus
+	achievements       *Achievements
+	sound              *SoundManager
+	screenWidth        int
+	viewport           *Viewport
 	playID             string
+	dailyDay           string
 	fixedRandomSeed    int64
-	touchContext       *touchutil.TouchContext
-	touchBuffer        []TouchRecord
-	random             *rand.Rand
+	input              Input
+	touchBuffer        telemetry.TouchBuffer
 	mode               GameMode
 	ticksFromModeStart uint64
-	hold               bool
-	score              int
 	rankingChan        <-chan []logging.GameScore
 	ranking            []logging.GameScore
-	fish               *Fish
-	bullets            []Bullet
-	splashEffects      []SplashEffect
-	enemies            []Enemy
+	world              *core.World
+	stats              *RoundStats
+	demo               *Demo
 	gainEffects        []GainEffect
-	leaves             []Leaf
-	timeInTicks        uint64
+	camera             *Camera
+	renderQueue        RenderQueue
 }
 
 func (g *Game) Update() error {
-	g.touchContext.Update()
-
 	g.ticksFromModeStart++
 
-	// Logging touches
-	if g.touchContext.IsBeingTouched() || g.touchContext.IsJustReleased() {
-		pos := g.touchContext.GetTouchPosition()
-		g.touchBuffer = append(g.touchBuffer, TouchRecord{
-			Ticks:        g.ticksFromModeStart,
-			JustTouched:  g.touchContext.IsJustTouched(),
-			JustReleased: g.touchContext.IsJustReleased(),
-			X:            pos.X,
-			Y:            pos.Y,
-		})
+	g.input.Update(g)
+
+	g.camera.Update()
+
+	g.sound.Update()
+
+	g.updateToasts()
+
+	if g.devMode != nil {
+		g.devMode.Update(g)
 	}
-	if len(g.touchBuffer) > 0 {
-		lastTicks := g.touchBuffer[len(g.touchBuffer)-1].Ticks
-		if len(g.touchBuffer) >= 60 ||
-			lastTicks > g.ticksFromModeStart ||
-			g.ticksFromModeStart-lastTicks > 60 {
-			g.sendLog(map[string]interface{}{
-				"touches": g.touchBuffer,
-			})
-			g.touchBuffer = nil
-		}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) ||
+		g.input.IsJustTouched() && g.isFullscreenButtonTouched() {
+		ebiten.SetFullscreen(!ebiten.IsFullscreen())
 	}
 
+	g.logTouches()
+
 	switch g.mode {
 	case GameModeTitle:
-		if g.touchContext.IsJustTouched() {
-			g.setNextMode(GameModePlaying)
-
-			g.sendLog(map[string]interface{}{
-				"action": "start_game",
-			})
-
-			audio.NewPlayerFromBytes(audioContext, gameStartAudioData).Play()
-		}
+		g.updateTitle()
 	case GameModePlaying:
-		if g.ticksFromModeStart%600 == 0 {
-			g.sendLog(map[string]interface{}{
-				"action": "playing",
-				"ticks":  g.ticksFromModeStart,
-				"score":  g.score,
+		if g.ticksFromModeStart%scoreSampleTicks == 0 {
+			g.sendLog(&telemetry.Heartbeat{
+				Ticks: g.ticksFromModeStart,
+				Score: g.world.Score,
 			})
-		}
-
-		if g.ticksFromModeStart > 3*60 {
-			if g.timeInTicks == 0 {
-				audio.NewPlayerFromBytes(audioContext, timeStartAudioData).Play()
 
-				bgmPlayer.Rewind()
-				bgmPlayer.Play()
-			}
-			g.timeInTicks++
+			g.stats.SampleScore(g.world)
 		}
 
-		if g.timeInTicks > 0 && g.touchContext.IsJustTouched() {
-			pos := g.touchContext.GetTouchPosition()
-			touchX, touchY := float64(pos.X), float64(pos.Y)
-			fishX, fishY := toScreenPosition(fishPosXInCamera, fishPosYInCamera, fishPosZInCamera)
-			if math.Pow(touchX-fishX, 2)+math.Pow(touchY-fishY, 2) < math.Pow(touchableR, 2) {
-				g.hold = true
-			}
+		action := core.Action{}
+		if g.input.IsJustTouched() {
+			pos := g.getTouchPosition()
+			action.Touch = true
+			action.TouchX, action.TouchY = float64(pos.X), float64(pos.Y)
+		}
+		if g.input.IsJustReleased() {
+			action.Release = true
+			action.ReleaseX, action.ReleaseY = g.getHoldPosition()
 		}
 
-		if g.hold && g.touchContext.IsJustReleased() {
-			g.hold = false
+		g.world.Update(action)
 
-			x, y := g.getHoldPosition()
-			bullet := g.newBulletByTouchPosition(x, y)
-			g.bullets = append(g.bullets, *bullet)
+		g.publishWorldEvents()
 
-			audio.NewPlayerFromBytes(audioContext, shootAudioData).Play()
+		g.stats.Update(g.world)
 
-			for i := 0; i < 5; i++ {
-				_, h := fishImages[0].Size()
-				g.splashEffects = append(g.splashEffects, SplashEffect{
-					x:  g.fish.x,
-					y:  g.fish.y - float64(h)/2,
-					z:  g.fish.z,
-					vx: 5.0 * math.Cos(math.Pi*g.random.Float64()),
-					vy: -10.0 * math.Sin(math.Pi*g.random.Float64()),
-				})
-			}
-		}
+		for _, e := range g.world.Events {
+			switch e.Kind {
+			case core.EventTimeStart:
+				g.sound.Play(sfx.TimeStart)
 
-		// Enemy enter
-		if g.ticksFromModeStart%60 == 0 {
-			for _, param := range []struct {
-				Kind                  EnemyKind
-				AppearanceProbability float64
-				Vx                    float64
-			}{
-				{
-					Kind:                  EnemyKindNormal,
-					AppearanceProbability: 0.25,
-					Vx:                    2.0,
-				},
-				{
-					Kind:                  EnemyKindDizzy,
-					AppearanceProbability: 0.20,
-					Vx:                    4.0,
-				},
-				{
-					Kind:                  EnemyKindShy,
-					AppearanceProbability: 0.10,
-					Vx:                    4.0,
-				},
-			} {
-				if g.random.Float64() < param.AppearanceProbability {
-					var xInScreen float64
-					if g.random.Int()%2 == 0 {
-						xInScreen = -50
-					} else {
-						xInScreen = screenWidth + 50
-					}
-
-					vx := param.Vx
-					if xInScreen > 0 {
-						vx *= -1
-					}
-
-					var yInScreen, enemyR float64
-					switch param.Kind {
-					case EnemyKindNormal:
-						yInScreen = normalEnemyYInScreen
-						enemyR = normalEnemyR
-					case EnemyKindDizzy:
-						yInScreen = dizzyEnemyYInScreen
-						enemyR = dizzyEnemyR
-					case EnemyKindShy:
-						yInScreen = shyEnemyYInScreen
-						enemyR = shyEnemyR
-					}
-
-					x, y := toCameraPosition(xInScreen, yInScreen, enemyZ)
-
-					g.enemies = append(g.enemies, Enemy{
-						kind: param.Kind,
-						x:    x,
-						y:    y,
-						z:    enemyZ,
-						vx:   vx,
-						r:    enemyR,
-						game: g,
-					})
-				}
-			}
-		}
+				g.sound.PlayMusic()
+			case core.EventShoot:
+				g.sound.Play(sfx.Shoot)
 
-		// Fish
-		g.fish.Update()
-
-		// Bullets
-		var newBullets []Bullet
-		for i := range g.bullets {
-			bullet := &g.bullets[i]
-
-			bullet.Update()
-
-			if bullet.y > 0 {
-				for i := 0; i < 5; i++ {
-					r := 10.0
-					g.splashEffects = append(g.splashEffects, SplashEffect{
-						x:  bullet.x,
-						y:  0,
-						z:  bullet.z,
-						vx: r * math.Cos(math.Pi*g.random.Float64()),
-						vy: -r * math.Sin(math.Pi*g.random.Float64()),
-					})
-				}
-			} else {
-				newBullets = append(newBullets, *bullet)
-			}
-		}
-		g.bullets = newBullets
+				g.sendLog(&telemetry.Shot{
+					Ticks: g.ticksFromModeStart,
+					VX:    e.Bullet.VX,
+					VY:    e.Bullet.VY,
+					VZ:    e.Bullet.VZ,
+				})
+			case core.EventSplash:
+				g.playSoundAt(sfx.Splash, e.Enemy.X, e.Enemy.Y, e.Enemy.Z)
+			case core.EventHit:
+				x, y := g.camera.ToScreenPosition(e.Enemy.X, e.Enemy.Y, e.Enemy.Z)
+				g.gainEffects = append(g.gainEffects, GainEffect{
+					x:     x,
+					y:     y,
+					score: e.Score,
+				})
 
-		// SplashEffects
-		var newSplashEffects []SplashEffect
-		for i := range g.splashEffects {
-			effect := &g.splashEffects[i]
+				if g.settings.ScreenShake {
+					g.camera.Shake(4)
+				}
 
-			effect.Update()
+				g.playSoundAt(sfx.Hit, e.Enemy.X, e.Enemy.Y, e.Enemy.Z)
 
-			if effect.y <= 100 {
-				newSplashEffects = append(newSplashEffects, *effect)
+				g.sendLog(&telemetry.Hit{
+					Ticks:     g.ticksFromModeStart,
+					EnemyKind: e.Enemy.Kind.String(),
+					Gain:      e.Score,
+					Score:     g.world.Score,
+				})
 			}
 		}
-		g.splashEffects = newSplashEffects
-
-		// Enemies
-		var newEnemies []Enemy
-		for i := range g.enemies {
-			enemy := &g.enemies[i]
-
-			enemy.Update()
-
-			if enemy.y > 0 {
-				for i := 0; i < 5; i++ {
-					r := 15.0
-					g.splashEffects = append(g.splashEffects, SplashEffect{
-						x:  enemy.x,
-						y:  0,
-						z:  enemy.z,
-						vx: r * math.Cos(math.Pi*g.random.Float64()),
-						vy: -r * math.Sin(math.Pi*g.random.Float64()),
-					})
-				}
 
-				audio.NewPlayerFromBytes(audioContext, splashAudioData).Play()
-
-				continue
-			}
+		// Music intensifies as the time runs out or the combo grows
+		if g.world.TimeInTicks > 0 {
+			remaining := finishTimeInTicks - g.world.TimeInTicks
+			g.sound.SetMusicLayer(MusicLayerDrums, remaining < 20*60 || g.world.Combo >= 3)
+			g.sound.SetMusicLayer(MusicLayerPulse, remaining < 10*60)
+		}
 
-			x, _ := toScreenPosition(enemy.x, enemy.y, enemy.z)
-			if x > -50 || x < screenWidth+50 {
-				newEnemies = append(newEnemies, *enemy)
-			}
+		if g.world.Hold {
+			g.camera.ZoomTo(1.05)
+		} else {
+			g.camera.ZoomTo(1)
 		}
-		g.enemies = newEnemies
 
 		// Gain effects
 		var newGainEffects []GainEffect
@@ -639,70 +395,52 @@ func (g *Game) Update() error {
 		}
 		g.gainEffects = newGainEffects
 
-		// Bullet and enemy collision
-		newBullets = nil
-		for i := range g.bullets {
-			b := &g.bullets[i]
-			hit := false
-			for j := range g.enemies {
-				e := &g.enemies[j]
-				if !e.hit && math.Pow(e.x-b.x, 2)+math.Pow(e.y-b.y, 2)+math.Pow(e.z-b.z, 2) < math.Pow(e.r+b.r, 2) {
-					e.hit = true
-					hit = true
-
-					var score int
-					switch e.kind {
-					case EnemyKindNormal:
-						score = 1
-					case EnemyKindDizzy:
-						score = 3
-					case EnemyKindShy:
-						score = 5
-					}
-					x, y := toScreenPosition(e.x, e.y, e.z)
-					g.gainEffects = append(g.gainEffects, GainEffect{
-						x:     x,
-						y:     y,
-						score: score,
-					})
-
-					g.score += score
-
-					audio.NewPlayerFromBytes(audioContext, hitAudioData).Play()
-
-					break
-				}
-			}
-			if !hit {
-				newBullets = append(newBullets, *b)
-			}
-		}
-		g.bullets = newBullets
+		if g.world.Finished() {
+			g.stats.SampleScore(g.world)
+
+			// The touches of the round are sent before its end
+			g.flushTouches()
 
-		if g.timeInTicks >= finishTimeInTicks {
-			g.sendLog(map[string]interface{}{
-				"action": "game_over",
-				"score":  g.score,
+			g.sendLog(&telemetry.GameOver{
+				Score:     g.world.Score,
+				Shots:     g.stats.Shots,
+				Hits:      g.stats.Hits,
+				SightMode: string(g.settings.SightMode),
+			})
+
+			g.events.Publish(GameEvent{
+				Kind:     GameEventGameOver,
+				Ticks:    g.world.TimeInTicks,
+				Score:    g.world.Score,
+				DailyDay: g.dailyDay,
 			})
 
 			g.setNextMode(GameModeGameOver)
 
-			audio.NewPlayerFromBytes(audioContext, gameOverAudioData).Play()
+			g.camera.ZoomTo(1)
+			g.camera.PanTo(0, -cameraHeight-40, -40, 240)
+
+			g.sound.Play(sfx.GameOver)
+			g.sound.PlayResultsJingle()
 
 			ch := make(chan []logging.GameScore, 1)
 
-			go (func(playerID string, playID string, score int, c chan<- []logging.GameScore) {
-				logging.RegisterScore(gameName, playerID, playID, score)
-				if ranking, err := logging.GetScoreList(gameName); err == nil {
+			go (func(rankingName, playerID string, playID string, score int, c chan<- []logging.GameScore) {
+				logging.RegisterScore(rankingName, playerID, playID, score)
+				if ranking, err := logging.GetScoreList(rankingName); err == nil {
 					c <- ranking
 				}
 				close(c)
-			})(g.playerID, g.playID, g.score, ch)
+			})(g.rankingName(), g.playerID, g.playID, g.world.Score, ch)
 
 			g.rankingChan = ch
 		}
 	case GameModeGameOver:
-		if g.ticksFromModeStart > 60 && g.touchContext.IsJustTouched() {
+		if g.ticksFromModeStart > 60 && g.isJustTouchedOnField() {
+			g.setNextMode(GameModeResults)
+		}
+	case GameModeResults:
+		if g.ticksFromModeStart > 60 && g.isJustTouchedOnField() {
 			select {
 			case ranking := <-g.rankingChan:
 				g.ranking = ranking
@@ -711,314 +449,462 @@ func (g *Game) Update() error {
 
 			if len(g.ranking) > 0 {
 				g.setNextMode(GameModeRanking)
-				audio.NewPlayerFromBytes(audioContext, rankingAudioData).Play()
+				g.sound.Play(sfx.Ranking)
 			} else {
 				g.initialize()
-				bgmPlayer.Pause()
+				g.sound.PauseMusic()
 			}
 		}
 	case GameModeRanking:
-		if len(g.ranking) == 0 || g.ticksFromModeStart > 60 && g.touchContext.IsJustTouched() {
+		if len(g.ranking) == 0 || g.ticksFromModeStart > 60 && g.isJustTouchedOnField() {
 			g.initialize()
-			bgmPlayer.Pause()
+			g.sound.PauseMusic()
 		}
+	case GameModeSettings:
+		g.updateSettings()
+	case GameModeAchievements:
+		g.updateAchievements()
 	}
 
 	return nil
 }
 
-func (g *Game) getHoldPosition() (float64, float64) {
-	pos := g.touchContext.GetTouchPosition()
-	x, y := float64(pos.X), float64(pos.Y)
+// getTouchPosition returns the touch position on the logical screen.
+func (g *Game) getTouchPosition() touchutil.TouchPosition {
+	return g.input.GetTouchPosition()
+}
 
-	_, fishY := toScreenPosition(fishPosXInCamera, fishPosYInCamera, fishPosZInCamera)
-	if x < 0 {
-		x = 0
-	}
-	if x > screenWidth {
-		x = screenWidth
-	}
-	if y < fishY {
-		y = fishY
-	}
-	if y > screenHeight {
-		y = screenHeight
-	}
+func (g *Game) isFullscreenButtonTouched() bool {
+	pos := g.getTouchPosition()
+	return pos.X >= fullscreenButtonX-5 && pos.X < fullscreenButtonX+fullscreenButtonSize+5 &&
+		pos.Y >= fullscreenButtonY-5 && pos.Y < fullscreenButtonY+fullscreenButtonSize+5
+}
 
-	return x, y
+// isJustTouchedOnField reports whether a touch started, except the ones on
+// the on-screen buttons.
+func (g *Game) isJustTouchedOnField() bool {
+	return g.input.IsJustTouched() && !g.isFullscreenButtonTouched()
 }
 
-func (g *Game) newBulletByTouchPosition(touchX, touchY float64) *Bullet {
-	fishX, fishY := toScreenPosition(fishPosXInCamera, fishPosYInCamera, fishPosZInCamera)
-
-	atan2 := math.Atan2(fishY-touchY, fishX-touchX)
-	d := math.Sqrt(math.Pow(fishX-touchX, 2) + math.Pow(fishY-touchY, 2))
-	r := 40 * d / (screenHeight - fishY)
-
-	return &Bullet{
-		x:  fishPosXInCamera,
-		y:  fishPosYInCamera,
-		z:  fishPosZInCamera,
-		vx: r * math.Cos(atan2),
-		vy: r * math.Sin(atan2),
-		vz: 3,
-		r:  bulletR,
+// playSoundAt plays the sound panned by the position on the screen and
+// attenuated by the distance from the fish on the screen, down to the half
+// at the screen width away.
+func (g *Game) playSoundAt(id SoundID, xInCamera, yInCamera, zInCamera float64) {
+	x, y := g.world.Projection.ToScreenPosition(xInCamera, yInCamera, zInCamera)
+	pan := (x - float64(g.screenWidth)/2) / (float64(g.screenWidth) / 2) * 0.8
+	fishX, fishY := g.world.FishScreenPosition()
+	gain := 1 - math.Min(1, math.Hypot(x-fishX, y-fishY)/float64(g.screenWidth))/2
+	g.sound.PlayAt(id, pan, gain)
+}
+
+// getHoldPosition returns the position where the fish is pulled to, from
+// which the bullet is shot.
+func (g *Game) getHoldPosition() (float64, float64) {
+	pos := g.getTouchPosition()
+	x, y := float64(pos.X), float64(pos.Y)
+
+	// The hold position is always on the pulling side. Mirror it on the
+	// fish when the drag points the direction to shoot.
+	if g.settings.ControlScheme == ControlSchemePush {
+		fishX, fishY := g.world.FishScreenPosition()
+		x, y = fishX*2-x, fishY*2-y
 	}
+
+	return g.world.ClampHoldPosition(x, y)
 }
 
 func (g *Game) drawWaterSurface(screen *ebiten.Image) {
-	ebitenutil.DrawRect(screen, 0, screenHeight/2, screenWidth, screenHeight/2, color.RGBA{0x0f, 0x5d, 0xfa, 0xff})
+	y := g.camera.HorizonY()
+	ebitenutil.DrawRect(screen, 0, y, float64(g.screenWidth), screenHeight-y, color.RGBA{0x0f, 0x5d, 0xfa, 0xff})
 }
 
 func (g *Game) drawScaffold(screen *ebiten.Image) {
-	ebitenutil.DrawRect(screen, 0, normalEnemyYInScreen+5, screenWidth, 10, color.RGBA{0xfa, 0x68, 0x35, 0xff})
-	ebitenutil.DrawRect(screen, 0, dizzyEnemyYInScreen+4, screenWidth, 10, color.RGBA{0xfa, 0x68, 0x35, 0xff})
-	ebitenutil.DrawRect(screen, 0, shyEnemyYInScreen+4, 90, 10, color.RGBA{0xfa, 0x68, 0x35, 0xff})
-	ebitenutil.DrawRect(screen, screenWidth-90, shyEnemyYInScreen+4, 90, 10, color.RGBA{0xfa, 0x68, 0x35, 0xff})
+	w := float64(g.screenWidth)
+	stub := g.world.ShyScaffoldWidth()
+	for _, s := range []struct {
+		x0, x1, y float64
+	}{
+		{-w, w * 2, core.NormalEnemyYInScreen + 5},
+		{-w, w * 2, core.DizzyEnemyYInScreen + 4},
+		{-w, stub, core.ShyEnemyYInScreen + 4},
+		{w - stub, w * 2, core.ShyEnemyYInScreen + 4},
+	} {
+		x0, y := g.world.Projection.ToCameraPosition(s.x0, s.y, enemyZ)
+		x1, _ := g.world.Projection.ToCameraPosition(s.x1, s.y, enemyZ)
+		x0s, ys := g.camera.ToScreenPosition(x0, y, enemyZ)
+		x1s, _ := g.camera.ToScreenPosition(x1, y, enemyZ)
+		h := 10 * g.camera.Magnification(enemyZ)
+		ebitenutil.DrawRect(screen, x0s, ys, x1s-x0s, h, color.RGBA{0xfa, 0x68, 0x35, 0xff})
+	}
 }
 
 func (g *Game) drawPhrase(screen *ebiten.Image) {
-	t := "Drag me!"
-	text.Draw(screen, t, fontS.Face, screenWidth/2-len(t)*int(fontS.FaceOptions.Size)/2, 320, color.White)
+	t := g.messages().DragMe
+	text.Draw(screen, t, fontS.Face, g.screenWidth/2-textLen(t)*int(fontS.FaceOptions.Size)/2, 320, color.White)
 }
 
 func (g *Game) drawSight(screen *ebiten.Image) {
-	fishX, fishY := toScreenPosition(fishPosXInCamera, fishPosYInCamera, fishPosZInCamera)
 	x, y := g.getHoldPosition()
-	ebitenutil.DrawLine(screen, fishX, fishY, x, y, color.White)
-
-	bullet := g.newBulletByTouchPosition(x, y)
-	t := (enemyZ - bullet.z) / bullet.vz
-	xt := bullet.x + bullet.vx*t
-	yt := bullet.y + bullet.vy*t + gravity/2*t*t
-	zt := enemyZ
-	if yt > 0 {
-		t = (-bullet.vy + math.Sqrt(math.Pow(bullet.vy, 2)-4*gravity/2*bullet.y)) / gravity
-		xt = bullet.x + bullet.vx*t
-		yt = 0
-		zt = bullet.z + bullet.vz*t
+
+	if g.settings.AimLine {
+		fishX, fishY := g.camera.ToScreenPosition(fishPosXInCamera, fishPosYInCamera, fishPosZInCamera)
+		lx, ly := x, y
+		if g.settings.ControlScheme == ControlSchemePush {
+			stageFishX, stageFishY := g.world.FishScreenPosition()
+			lx, ly = stageFishX*2-x, stageFishY*2-y
+		}
+		ebitenutil.DrawLine(screen, fishX, fishY, lx, ly, color.White)
+	}
+
+	if g.settings.SightMode == SightModeHidden || !g.settings.Difficulty.Preset().LandingCircle {
+		return
+	}
+
+	bullet := g.world.NewBullet(x, y)
+	p := geom.Vec3{X: bullet.X, Y: bullet.Y, Z: bullet.Z}
+	v := geom.Vec3{X: bullet.VX, Y: bullet.VY, Z: bullet.VZ}
+	landing, ticks, ok := geom.Landing(p, v, g.world.Tuning.Gravity, enemyZ)
+	if !ok {
+		return
 	}
-	xts, yts := toScreenPosition(xt, yt, zt)
-	ebitenutil.DrawCircle(screen, xts, yts, 10, color.RGBA{0, 0, 0, 0x30})
+
+	if g.settings.SightMode == SightModeAssisted {
+		g.drawAssistedSight(screen, bullet, ticks)
+	}
+
+	xs, ys := g.camera.ToScreenPosition(landing.X, landing.Y, landing.Z)
+	ebitenutil.DrawCircle(screen, xs, ys, 10, color.RGBA{0, 0, 0, 0x30})
 }
 
 func (g *Game) drawTime(screen *ebiten.Image) {
 	if g.mode == GameModePlaying && g.ticksFromModeStart < 3*60 {
 		timeText := fmt.Sprintf("%d", int(math.Ceil(float64(3*60-g.ticksFromModeStart)/60)))
-		text.Draw(screen, timeText, fontL.Face, screenWidth/2-len(timeText)*int(fontL.FaceOptions.Size)/2, 260, color.White)
+		text.Draw(screen, timeText, fontL.Face, g.screenWidth/2-len(timeText)*int(fontL.FaceOptions.Size)/2, 260, color.White)
 	} else {
-		timeText := fmt.Sprintf("%d", int(math.Ceil(float64(finishTimeInTicks-g.timeInTicks)/60)))
-		text.Draw(screen, timeText, fontS.Face, screenWidth/2-len(timeText)*int(fontS.FaceOptions.Size)/2, 20, color.White)
+		timeText := fmt.Sprintf("%d", int(math.Ceil(float64(finishTimeInTicks-g.world.TimeInTicks)/60)))
+		text.Draw(screen, timeText, fontS.Face, g.screenWidth/2-len(timeText)*int(fontS.FaceOptions.Size)/2, 20, color.White)
 	}
 }
 
 func (g *Game) drawScore(screen *ebiten.Image) {
-	scoreText := fmt.Sprintf("SCORE %d", g.score)
-	text.Draw(screen, scoreText, fontS.Face, screenWidth-(len(scoreText)+1)*int(fontS.FaceOptions.Size), 20, color.White)
+	scoreText := fmt.Sprintf("SCORE %d", g.world.Score)
+	text.Draw(screen, scoreText, fontS.Face, g.screenWidth-(len(scoreText)+1)*int(fontS.FaceOptions.Size), 20, color.White)
+}
+
+func (g *Game) drawFullscreenButton(screen *ebiten.Image) {
+	x, y, s, l := float64(fullscreenButtonX), float64(fullscreenButtonY), float64(fullscreenButtonSize), 6.0
+	if ebiten.IsFullscreen() {
+		x, y, s = x+l, y+l, s-l*2
+		l *= -1
+	}
+	c := color.RGBA{0xff, 0xff, 0xff, 0xc0}
+	for _, corner := range []struct {
+		x, y, dx, dy float64
+	}{
+		{x, y, l, l},
+		{x + s, y, -l, l},
+		{x, y + s, l, -l},
+		{x + s, y + s, -l, -l},
+	} {
+		ebitenutil.DrawLine(screen, corner.x, corner.y, corner.x+corner.dx, corner.y, c)
+		ebitenutil.DrawLine(screen, corner.x, corner.y, corner.x, corner.y+corner.dy, c)
+	}
+}
+
+// textLen returns the number of the characters, which is proportional to
+// the width of the text in the monospace font.
+func textLen(s string) int {
+	return utf8.RuneCountInString(s)
 }
 
 func (g *Game) drawTitle(screen *ebiten.Image) {
 	titleText := []string{"ARCHERFISH"}
 	for i, s := range titleText {
-		text.Draw(screen, s, fontL.Face, screenWidth/2-len(s)*int(fontL.FaceOptions.Size)/2, 75+i*int(fontL.FaceOptions.Size*1.8), color.RGBA{0, 0, 0x50, 0xff})
+		text.Draw(screen, s, fontL.Face, g.screenWidth/2-len(s)*int(fontL.FaceOptions.Size)/2, 75+i*int(fontL.FaceOptions.Size*1.8), color.RGBA{0, 0, 0x50, 0xff})
 	}
 
-	usageTexts := []string{"[DRAG] Set sights on", "[RELEASE] Shoot"}
+	usageTexts := g.messages().Usage
 	for i, s := range usageTexts {
-		text.Draw(screen, s, fontS.Face, screenWidth/2-len(s)*int(fontS.FaceOptions.Size)/2, 280+i*int(fontS.FaceOptions.Size*1.8), color.White)
+		text.Draw(screen, s, fontS.Face, g.screenWidth/2-textLen(s)*int(fontS.FaceOptions.Size)/2, 280+i*int(fontS.FaceOptions.Size*1.8), color.White)
 	}
 
-	creditTexts := []string{"CREATOR: NAOKI TSUJIO", "FONT: Press Start 2P by CodeMan38", "SOUND EFFECT: MaouDamashii"}
+	creditTexts := g.messages().Credits
 	for i, s := range creditTexts {
-		text.Draw(screen, s, fontS.Face, screenWidth/2-len(s)*int(fontS.FaceOptions.Size)/2, 420+i*int(fontS.FaceOptions.Size*1.8), color.White)
+		text.Draw(screen, s, fontS.Face, g.screenWidth/2-textLen(s)*int(fontS.FaceOptions.Size)/2, 420+i*int(fontS.FaceOptions.Size*1.8), color.White)
 	}
 }
 
 func (g *Game) drawGameOver(screen *ebiten.Image) {
-	gameOverText := "GAME OVER"
-	text.Draw(screen, gameOverText, fontL.Face, screenWidth/2-len(gameOverText)*int(fontL.FaceOptions.Size)/2, 185, color.White)
-	scoreText := []string{"YOUR SCORE IS", fmt.Sprintf("%d!", g.score)}
+	gameOverText := g.messages().GameOver
+	text.Draw(screen, gameOverText, fontL.Face, g.screenWidth/2-textLen(gameOverText)*int(fontL.FaceOptions.Size)/2, 185, color.White)
+	scoreText := []string{g.messages().YourScoreIs, fmt.Sprintf("%d!", g.world.Score)}
 	for i, s := range scoreText {
-		text.Draw(screen, s, fontM.Face, screenWidth/2-len(s)*int(fontM.FaceOptions.Size)/2, 275+i*int(fontM.FaceOptions.Size*2), color.White)
+		text.Draw(screen, s, fontM.Face, g.screenWidth/2-textLen(s)*int(fontM.FaceOptions.Size)/2, 275+i*int(fontM.FaceOptions.Size*2), color.White)
 	}
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	screen.Fill(color.RGBA{0xc7, 0xd7, 0xc7, 0xff})
+func (g *Game) submitWorld(q *RenderQueue, w *core.World) {
+	q.Submit(RenderLayerWorld, enemyZ, g.drawScaffold)
 
-	g.drawWaterSurface(screen)
+	for i := range w.Enemies {
+		submitEnemy(q, &w.Enemies[i], g.camera)
+	}
 
-	switch g.mode {
-	case GameModeTitle:
-		g.drawTitle(screen)
-
-		g.drawScaffold(screen)
-
-		for _, t := range []struct {
-			kind      EnemyKind
-			xInScreen float64
-			vx        float64
-		}{
-			{
-				kind:      EnemyKindNormal,
-				xInScreen: 100,
-				vx:        1,
-			},
-			{
-				kind:      EnemyKindNormal,
-				xInScreen: screenWidth - 70,
-				vx:        -1,
-			},
-			{
-				kind:      EnemyKindDizzy,
-				xInScreen: 250,
-				vx:        1,
-			},
-			{
-				kind:      EnemyKindDizzy,
-				xInScreen: screenWidth - 150,
-				vx:        -1,
-			},
-			{
-				kind:      EnemyKindShy,
-				xInScreen: 50,
-				vx:        1,
-			},
-		} {
-			x, y := toCameraPosition(t.xInScreen, map[EnemyKind]float64{
-				EnemyKindNormal: normalEnemyYInScreen,
-				EnemyKindDizzy:  dizzyEnemyYInScreen,
-				EnemyKindShy:    shyEnemyYInScreen,
-			}[t.kind], enemyZ)
-			e := &Enemy{
-				kind: t.kind,
-				x:    x,
-				y:    y,
-				z:    enemyZ,
-				vx:   t.vx,
-				r: map[EnemyKind]float64{
-					EnemyKindNormal: normalEnemyR,
-					EnemyKindDizzy:  dizzyEnemyR,
-					EnemyKindShy:    shyEnemyR,
-				}[t.kind],
-			}
-			e.Draw(screen)
-		}
+	for i := range w.Leaves {
+		submitLeaf(q, &w.Leaves[i], g.camera)
+	}
 
-		for i := range g.leaves {
-			g.leaves[i].Draw(screen)
-		}
+	for i := range w.SplashEffects {
+		submitSplashEffect(q, &w.SplashEffects[i], g.camera)
+	}
 
-		g.fish.Draw(screen)
-	case GameModePlaying:
-		for i := range g.bullets {
-			if g.bullets[i].z > enemyZ {
-				g.bullets[i].Draw(screen)
-			}
-		}
+	for i := range w.Bullets {
+		submitBullet(q, &w.Bullets[i], g.camera)
+	}
 
-		g.drawScaffold(screen)
+	submitFish(q, &w.Fish, w.Hold, g.camera)
+}
 
-		for i := range g.enemies {
-			g.enemies[i].Draw(screen)
+// submitTitleScene submits the stage decorated with the enemies standing
+// still.
+func (g *Game) submitTitleScene(q *RenderQueue) {
+	q.Submit(RenderLayerWorld, enemyZ, g.drawScaffold)
+
+	for _, t := range []struct {
+		kind      core.EnemyKind
+		xInScreen float64
+		vx        float64
+	}{
+		{
+			kind:      core.EnemyKindNormal,
+			xInScreen: 100,
+			vx:        1,
+		},
+		{
+			kind:      core.EnemyKindNormal,
+			xInScreen: float64(g.screenWidth) - 70,
+			vx:        -1,
+		},
+		{
+			kind:      core.EnemyKindDizzy,
+			xInScreen: 250,
+			vx:        1,
+		},
+		{
+			kind:      core.EnemyKindDizzy,
+			xInScreen: float64(g.screenWidth) - 150,
+			vx:        -1,
+		},
+		{
+			kind:      core.EnemyKindShy,
+			xInScreen: 50,
+			vx:        1,
+		},
+	} {
+		x, y := g.world.Projection.ToCameraPosition(t.xInScreen, t.kind.YInScreen(), enemyZ)
+		e := &core.Enemy{
+			Kind: t.kind,
+			X:    x,
+			Y:    y,
+			Z:    enemyZ,
+			VX:   t.vx,
+			R:    g.tuning.Enemy(t.kind).R,
 		}
+		submitEnemy(q, e, g.camera)
+	}
 
-		for i := range g.leaves {
-			g.leaves[i].Draw(screen)
-		}
+	for i := range g.world.Leaves {
+		submitLeaf(q, &g.world.Leaves[i], g.camera)
+	}
 
-		for i := range g.splashEffects {
-			g.splashEffects[i].Draw(screen)
-		}
+	submitFish(q, &g.world.Fish, false, g.camera)
+}
 
-		for i := range g.bullets {
-			if g.bullets[i].z <= enemyZ {
-				g.bullets[i].Draw(screen)
-			}
-		}
+func (g *Game) Draw(screen *ebiten.Image) {
+	q := &g.renderQueue
 
-		for i := range g.gainEffects {
-			g.gainEffects[i].Draw(screen)
-		}
+	q.Submit(RenderLayerBackground, 0, func(screen *ebiten.Image) {
+		screen.Fill(color.RGBA{0xc7, 0xd7, 0xc7, 0xff})
+		g.drawWaterSurface(screen)
+	})
 
-		g.fish.Draw(screen)
+	switch g.mode {
+	case GameModeTitle:
+		if g.demo != nil {
+			g.submitWorld(q, g.demo.world)
 
-		if g.timeInTicks > 0 && !g.hold && g.score == 0 {
-			g.drawPhrase(screen)
-		}
+			q.Submit(RenderLayerHUD, 0, g.drawDemo)
+		} else {
+			q.Submit(RenderLayerBackground, 0, g.drawTitle)
 
-		if g.hold {
-			g.drawSight(screen)
-		}
+			q.Submit(RenderLayerHUD, 0, g.drawDifficultySelector)
 
-		g.drawTime(screen)
-		g.drawScore(screen)
-	case GameModeGameOver, GameModeRanking:
-		for i := range g.bullets {
-			if g.bullets[i].z > enemyZ {
-				g.bullets[i].Draw(screen)
-			}
-		}
+			q.Submit(RenderLayerHUD, 0, g.drawSettingsButton)
 
-		g.drawScaffold(screen)
+			q.Submit(RenderLayerHUD, 0, g.drawAchievementsButton)
 
-		for i := range g.enemies {
-			g.enemies[i].Draw(screen)
+			q.Submit(RenderLayerHUD, 0, g.drawDailyButton)
+
+			g.submitTitleScene(q)
 		}
+	case GameModePlaying:
+		g.submitWorld(q, g.world)
 
-		for i := range g.leaves {
-			g.leaves[i].Draw(screen)
+		for i := range g.gainEffects {
+			g.gainEffects[i].Submit(q)
 		}
 
-		for i := range g.splashEffects {
-			g.splashEffects[i].Draw(screen)
+		if g.world.TimeInTicks > 0 && !g.world.Hold && g.world.Score == 0 {
+			q.Submit(RenderLayerOverlay, 0, g.drawPhrase)
 		}
 
-		for i := range g.bullets {
-			if g.bullets[i].z <= enemyZ {
-				g.bullets[i].Draw(screen)
-			}
+		if g.world.Hold {
+			q.Submit(RenderLayerOverlay, 0, g.drawSight)
 		}
 
-		g.fish.Draw(screen)
+		q.Submit(RenderLayerHUD, 0, g.drawTime)
+		q.Submit(RenderLayerHUD, 0, g.drawScore)
+		if g.dailyDay != "" {
+			q.Submit(RenderLayerHUD, 0, g.drawDailyLabel)
+		}
+	case GameModeGameOver, GameModeResults, GameModeRanking:
+		g.submitWorld(q, g.world)
 
-		g.drawTime(screen)
-		g.drawScore(screen)
+		q.Submit(RenderLayerHUD, 0, g.drawTime)
+		q.Submit(RenderLayerHUD, 0, g.drawScore)
+		if g.dailyDay != "" {
+			q.Submit(RenderLayerHUD, 0, g.drawDailyLabel)
+		}
 
 		if g.mode == GameModeGameOver {
-			g.drawGameOver(screen)
+			q.Submit(RenderLayerHUD, 0, g.drawGameOver)
+		} else if g.mode == GameModeResults {
+			q.Submit(RenderLayerHUD, 0, g.drawResults)
 		} else if g.mode == GameModeRanking {
-			drawutil.DrawRanking(screen, g.ranking, &drawutil.DrawRankingOption{
-				TitleFont: fontL,
-				BodyFont:  fontM,
-				PlayerID:  g.playerID,
+			q.Submit(RenderLayerHUD, 0, func(screen *ebiten.Image) {
+				drawutil.DrawRanking(screen, g.ranking, &drawutil.DrawRankingOption{
+					TitleFont:   fontL,
+					BodyFont:    fontM,
+					ScreenWidth: g.screenWidth,
+					PlayerID:    g.playerID,
+				})
 			})
 		}
+	case GameModeSettings:
+		g.submitTitleScene(q)
+
+		q.Submit(RenderLayerHUD, 0, g.drawSettings)
+	case GameModeAchievements:
+		g.submitTitleScene(q)
+
+		q.Submit(RenderLayerHUD, 0, g.drawAchievements)
 	}
+
+	q.Submit(RenderLayerHUD, 0, g.drawFullscreenButton)
+	q.Submit(RenderLayerHUD, 0, g.drawToasts)
+
+	world, ui := g.viewport.Canvases(g.screenWidth, screenHeight)
+	q.Flush(func(layer RenderLayer) *ebiten.Image {
+		if layer >= RenderLayerOverlay {
+			return ui
+		}
+		return world
+	})
+
+	g.viewport.Present(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
+	return g.viewport.Layout(outsideWidth, outsideHeight, g.screenWidth, screenHeight)
 }
 
-func (g *Game) sendLog(payload map[string]interface{}) {
-	p := map[string]interface{}{
-		"player_id": g.playerID,
-		"play_id":   g.playID,
+func (g *Game) logEncoder() *telemetry.Encoder {
+	return &telemetry.Encoder{
+		PlayerID:   g.playerID,
+		PlayID:     g.playID,
+		Difficulty: g.difficulty(),
 	}
-	for k, v := range payload {
-		p[k] = v
+}
+
+// sendLog sends the event stamped with the current play.
+func (g *Game) sendLog(e telemetry.Event) {
+	g.logEncoder().Stamp(e)
+
+	logging.LogAsync(gameName, e)
+
+	if g.logFile != nil {
+		g.logFile.Write(e)
 	}
+}
 
-	logging.LogAsync(gameName, p)
+// logTouches buffers the touch of the tick, and sends the batch when due.
+func (g *Game) logTouches() {
+	if g.input.IsBeingTouched() || g.input.IsJustReleased() {
+		pos := g.getTouchPosition()
+		g.touchBuffer.Add(telemetry.TouchRecord{
+			Ticks:        g.ticksFromModeStart,
+			JustTouched:  g.input.IsJustTouched(),
+			JustReleased: g.input.IsJustReleased(),
+			X:            pos.X,
+			Y:            pos.Y,
+			Mode:         g.mode.String(),
+			PlayID:       g.playID,
+		})
+	}
+	if g.touchBuffer.Due(g.ticksFromModeStart) {
+		g.flushTouches()
+	}
+}
+
+// flushTouches sends the buffered touches, which is done before the mode
+// or the play changes.
+func (g *Game) flushTouches() {
+	if batch := g.touchBuffer.Take(); batch != nil {
+		g.sendLog(batch)
+	}
+}
+
+// Close sends the touches left in the buffer before the game exits. They
+// are sent synchronously, as the process ends right after.
+func (g *Game) Close() {
+	batch := g.touchBuffer.Take()
+	if batch == nil {
+		return
+	}
+	g.logEncoder().Stamp(batch)
+
+	logging.Log(gameName, batch)
+
+	if g.logFile != nil {
+		g.logFile.Write(batch)
+	}
 }
 
 func (g *Game) setNextMode(mode GameMode) {
+	g.flushTouches()
+
 	g.mode = mode
 	g.ticksFromModeStart = 0
 }
 
+// initialize starts a new play on the title.
 func (g *Game) initialize() {
+	g.initializePlay("")
+}
+
+// initializePlay starts a new play, which is the daily challenge of the
+// day unless it is empty.
+func (g *Game) initializePlay(dailyDay string) {
+	g.flushTouches()
+
+	g.dailyDay = dailyDay
+
+	if g.settings.Widescreen {
+		g.screenWidth = wideScreenWidth
+	} else {
+		g.screenWidth = standardScreenWidth
+	}
+
 	var playID string
 	if playIDObj, err := uuid.NewRandom(); err == nil {
 		playID = playIDObj.String()
@@ -1026,58 +912,62 @@ func (g *Game) initialize() {
 	g.playID = playID
 
 	var seed int64
-	if g.fixedRandomSeed != 0 {
+	if dailyDay != "" {
+		seed = dailySeed(dailyDay)
+	} else if g.fixedRandomSeed != 0 {
 		seed = g.fixedRandomSeed
 	} else {
 		seed = time.Now().Unix()
 	}
 
-	g.sendLog(map[string]interface{}{
-		"action": "initialize",
-		"seed":   seed,
+	g.sendLog(&telemetry.SessionInit{
+		Seed:          seed,
+		Rules:         core.RulesVersion,
+		ScreenWidth:   g.screenWidth,
+		ControlScheme: string(g.settings.ControlScheme),
+		Daily:         dailyDay,
 	})
 
-	g.random = rand.New(rand.NewSource(seed))
-	g.hold = false
-	g.score = 0
 	g.rankingChan = nil
 	g.ranking = nil
-	g.fish = &Fish{
-		game: g,
-		x:    fishPosXInCamera,
-		y:    fishPosYInCamera,
-		z:    fishPosZInCamera,
-	}
-	g.bullets = nil
-	g.splashEffects = nil
-	g.enemies = nil
+	g.world = core.NewWorld(seed, g.roundTuning(), g.screenWidth)
+	g.stats = newRoundStats()
 	g.gainEffects = nil
-	g.leaves = nil
-	g.timeInTicks = 0
-
-	for _, baseY := range []float64{normalEnemyYInScreen, dizzyEnemyYInScreen, shyEnemyYInScreen} {
-		x := -50.0
-		for x < screenWidth {
-			x += 100 + g.random.NormFloat64()*20
-			if baseY == shyEnemyYInScreen {
-				if x > 90 && x < screenWidth-90 {
-					continue
-				}
-			}
-			g.leaves = append(g.leaves, Leaf{
-				xInScreen: x,
-				yInScreen: baseY + 13 + g.random.NormFloat64()*1,
-				scaleX:    (1 + g.random.NormFloat64()*0.1) * float64(g.random.Int()%2*2-1),
-				scaleY:    1 + g.random.NormFloat64()*0.1,
-			})
-		}
-	}
+	g.demo = nil
+	g.camera = newCamera(g.screenWidth)
+	g.achievements.StartRound()
 
 	g.setNextMode(GameModeTitle)
 }
 
 func main() {
-	if os.Getenv("GAME_LOGGING") == "1" {
+	config, err := parseConfig(os.Args[0], os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\nRun with -help for usage.\n", err)
+		os.Exit(2)
+	}
+
+	var replay *Replay
+	if config.Replay != "" {
+		f, err := os.Open(config.Replay)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		replay, err = readReplay(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", config.Replay, err)
+			os.Exit(1)
+		}
+	}
+
+	// Replays and bot plays are not logged, so that their scores are not
+	// registered
+	if config.Logging && replay == nil && config.Bot == "" {
 		secret, err := resources.ReadFile("resources/secret")
 		if err == nil {
 			logging.Enable(string(secret))
@@ -1086,29 +976,81 @@ func main() {
 		logging.Disable()
 	}
 
-	var randomSeed int64
-	if seed, err := strconv.Atoi(os.Getenv("GAME_RAND_SEED")); err == nil {
-		randomSeed = int64(seed)
+	randomSeed := config.Seed
+	if replay != nil {
+		randomSeed = replay.Seed
 	}
 
-	playerID := os.Getenv("GAME_PLAYER_ID")
+	playerID := config.PlayerID
 	if playerID == "" {
 		if playerIDObj, err := uuid.NewRandom(); err == nil {
 			playerID = playerIDObj.String()
 		}
 	}
 
-	ebiten.SetWindowSize(screenWidth, screenHeight)
-	ebiten.SetWindowTitle("Archerfish")
+	settings, settingsErr := loadSettings()
+	if replay != nil {
+		settings = replay.Settings(settings)
+	}
+	viewport := newViewport(settings.ScalingMode)
+
+	var input Input = newTouchInput(viewport)
+	if replay != nil {
+		input = newReplayInput(replay)
+	} else if level, ok := bot.ParseLevel(config.Bot); ok {
+		input = newBotInput(level, time.Now().UnixNano())
+	}
 
 	game := &Game{
 		playerID:        playerID,
+		settings:        settings,
+		tuning:          core.DefaultTuning(),
+		sound:           newSoundManager(audioContext),
+		viewport:        viewport,
 		fixedRandomSeed: randomSeed,
-		touchContext:    touchutil.CreateTouchContext(),
+		input:           input,
+		// The settings of the replays are the recorded ones, not to be
+		// saved as the player's
+		persistSettings: replay == nil,
 	}
+	// Achievements are kept per player ID, and only the ones of the real
+	// plays are saved
+	profile := config.PlayerID
+	if profile == "" {
+		profile = "default"
+	}
+	game.achievements = newAchievements(profile, replay == nil && config.Bot == "")
+	game.achievements.Subscribe(&game.events, game.showAchievementToast)
+
+	if config.LogFile != "" {
+		game.logFile, err = openLogFile(config.LogFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer game.logFile.Close()
+	}
+
+	if config.Dev {
+		game.devMode = newDevMode(config.Tuning, "resources/sprites")
+		game.devMode.Start(game)
+	}
+
+	game.applySettings()
 	game.initialize()
+	game.setNextMode(config.GameMode())
+	if settingsErr != nil {
+		game.showToast(settingsErr.Error(), toastColorError)
+	}
+
+	ebiten.SetWindowSize(game.screenWidth, screenHeight)
+	ebiten.SetWindowTitle("Archerfish")
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetFullscreen(config.Fullscreen)
 
-	if err := ebiten.RunGame(game); err != nil {
+	err = ebiten.RunGame(game)
+	game.Close()
+	if err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/telemetry"
+	logging "github.com/tsujio/game-logging-server/client"
+	"github.com/tsujio/game-util/touchutil"
+)
+
+// scriptedInput is the touch set by the test for each tick.
+type scriptedInput struct {
+	touching, justTouched, justReleased bool
+	pos                                 touchutil.TouchPosition
+}
+
+func (i *scriptedInput) Update(g *Game)                            {}
+func (i *scriptedInput) IsJustTouched() bool                       { return i.justTouched }
+func (i *scriptedInput) IsBeingTouched() bool                      { return i.touching }
+func (i *scriptedInput) IsJustReleased() bool                      { return i.justReleased }
+func (i *scriptedInput) GetTouchPosition() touchutil.TouchPosition { return i.pos }
+
+// touchLogTest drives the touch logging of a Game, recording what it is
+// given to compare with the log file.
+type touchLogTest struct {
+	g        *Game
+	input    *scriptedInput
+	recorded []telemetry.TouchRecord
+}
+
+// tick runs the touch logging of a tick of Update, in which the touch is
+// held, just touched, just released or none.
+func (tt *touchLogTest) tick(touching, justTouched, justReleased bool) {
+	g := tt.g
+	g.ticksFromModeStart++
+
+	*tt.input = scriptedInput{
+		touching:     touching,
+		justTouched:  justTouched,
+		justReleased: justReleased,
+		pos:          touchutil.TouchPosition{X: int(g.ticksFromModeStart) % 640, Y: 240},
+	}
+	if touching || justReleased {
+		tt.recorded = append(tt.recorded, telemetry.TouchRecord{
+			Ticks:        g.ticksFromModeStart,
+			JustTouched:  justTouched,
+			JustReleased: justReleased,
+			X:            tt.input.pos.X,
+			Y:            tt.input.pos.Y,
+			Mode:         g.mode.String(),
+			PlayID:       g.playID,
+		})
+	}
+
+	g.logTouches()
+}
+
+// hold touches for the ticks and releases.
+func (tt *touchLogTest) hold(ticks int) {
+	tt.tick(true, true, false)
+	for i := 1; i < ticks; i++ {
+		tt.tick(true, false, false)
+	}
+	tt.tick(false, false, true)
+}
+
+func (tt *touchLogTest) wait(ticks int) {
+	for i := 0; i < ticks; i++ {
+		tt.tick(false, false, false)
+	}
+}
+
+func TestTouchLogFlush(t *testing.T) {
+	logging.Disable()
+
+	path := filepath.Join(t.TempDir(), "log.ndjson")
+	logFile, err := openLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := &scriptedInput{}
+	g := &Game{
+		playerID: "player",
+		settings: defaultSettings(),
+		tuning:   core.DefaultTuning(),
+		input:    input,
+		logFile:  logFile,
+	}
+	g.achievements = newAchievements("test", false)
+	tt := &touchLogTest{g: g, input: input}
+
+	var playIDs []string
+	for play := 0; play < 2; play++ {
+		g.initialize()
+		playIDs = append(playIDs, g.playID)
+
+		// Tap the title, going to the next mode while still touching
+		tt.wait(30)
+		tt.tick(true, true, false)
+		g.setNextMode(GameModePlaying)
+		tt.tick(false, false, true)
+
+		// Hold longer than a batch of 60 touches, and then pause
+		tt.hold(70)
+		tt.wait(90)
+		tt.hold(5)
+
+		if play == 0 {
+			// Let the round end while touching, and start a new play
+			// without a mode change in between
+			g.setNextMode(GameModeGameOver)
+			tt.hold(3)
+			tt.tick(true, true, false)
+		}
+	}
+	if g.playID == playIDs[0] {
+		t.Fatal("the plays have the same ID")
+	}
+
+	// Exit while touching
+	tt.tick(true, false, false)
+	g.Close()
+	if err := logFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var logged []telemetry.TouchRecord
+	batches := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		e, err := telemetry.Decode(scanner.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch, ok := e.(*telemetry.TouchBatch)
+		if !ok {
+			continue
+		}
+		batches++
+
+		h := telemetry.HeaderOf(batch)
+		for _, r := range batch.Touches {
+			if r.PlayID != h.PlayID || r.Mode != batch.Touches[0].Mode {
+				t.Errorf("a batch of %s in %s holds a touch of %s in %s", h.PlayID, batch.Touches[0].Mode, r.PlayID, r.Mode)
+			}
+		}
+		logged = append(logged, batch.Touches...)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(logged, tt.recorded) {
+		t.Errorf("%d touches logged in %d batches, want the %d given", len(logged), batches, len(tt.recorded))
+	}
+	if g.touchBuffer.Take() != nil {
+		t.Error("touches are left in the buffer after Close")
+	}
+}
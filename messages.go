@@ -0,0 +1,169 @@
+package main
+
+type Language string
+
+const (
+	LanguageEnglish Language = "en"
+	LanguageSpanish Language = "es"
+)
+
+var languages = []Language{LanguageEnglish, LanguageSpanish}
+
+// Messages holds the texts shown in the game. The font only covers Latin
+// characters, so languages are limited to the ones written with them.
+type Messages struct {
+	LanguageName     string
+	Usage            []string
+	Credits          []string
+	DragMe           string
+	GameOver         string
+	YourScoreIs      string
+	Settings         string
+	Back             string
+	On, Off          string
+	BGMVolume        string
+	SFXVolume        string
+	Mute             string
+	AimLine          string
+	Sight            string
+	SightNormal      string
+	SightAssisted    string
+	SightHidden      string
+	Controls         string
+	ControlPull      string
+	ControlPush      string
+	Language         string
+	ScreenShake      string
+	Widescreen       string
+	Scaling          string
+	ScalingPixel     string
+	ScalingSmooth    string
+	Demo             string
+	DifficultyEasy   string
+	DifficultyNormal string
+	DifficultyHard   string
+	Achievements     string
+	Daily            string
+	Results          string
+	Shots            string
+	Hits             string
+	Accuracy         string
+	LongestCombo     string
+	FastestKill      string
+	// AchievementUnlocked prefixes the name of an unlocked achievement
+	AchievementUnlocked string
+	AchievementTexts    map[AchievementID]AchievementText
+}
+
+type AchievementText struct {
+	Name        string
+	Description string
+}
+
+var messageCatalog = map[Language]*Messages{
+	LanguageEnglish: {
+		LanguageName:        "ENGLISH",
+		Usage:               []string{"[DRAG] Set sights on", "[RELEASE] Shoot"},
+		Credits:             []string{"CREATOR: NAOKI TSUJIO", "FONT: Press Start 2P by CodeMan38", "SOUND EFFECT: MaouDamashii"},
+		DragMe:              "Drag me!",
+		GameOver:            "GAME OVER",
+		YourScoreIs:         "YOUR SCORE IS",
+		Settings:            "SETTINGS",
+		Back:                "BACK",
+		On:                  "ON",
+		Off:                 "OFF",
+		BGMVolume:           "BGM VOLUME",
+		SFXVolume:           "SFX VOLUME",
+		Mute:                "MUTE",
+		AimLine:             "AIM LINE",
+		Sight:               "SIGHT",
+		SightNormal:         "NORMAL",
+		SightAssisted:       "ASSISTED",
+		SightHidden:         "HIDDEN",
+		Controls:            "CONTROLS",
+		ControlPull:         "PULL",
+		ControlPush:         "PUSH",
+		Language:            "LANGUAGE",
+		ScreenShake:         "SCREEN SHAKE",
+		Widescreen:          "WIDESCREEN",
+		Scaling:             "SCALING",
+		ScalingPixel:        "PIXEL",
+		ScalingSmooth:       "SMOOTH",
+		Demo:                "DEMO",
+		DifficultyEasy:      "EASY",
+		DifficultyNormal:    "NORMAL",
+		DifficultyHard:      "HARD",
+		Achievements:        "ACHIEVEMENTS",
+		Daily:               "DAILY",
+		Results:             "RESULTS",
+		Shots:               "SHOTS FIRED",
+		Hits:                "HITS",
+		Accuracy:            "ACCURACY",
+		LongestCombo:        "LONGEST COMBO",
+		FastestKill:         "FASTEST KILL",
+		AchievementUnlocked: "UNLOCKED",
+		AchievementTexts: map[AchievementID]AchievementText{
+			AchievementShyHunter:    {"SHY HUNTER", "Hit 10 shy bugs in a round"},
+			AchievementCentury:      {"CENTURY", "Score 100 in a round"},
+			AchievementSharpshooter: {"SHARPSHOOTER", "Finish with 90% accuracy (10+ hits)"},
+			AchievementMultiKill:    {"MULTI-KILL", "Drop 3 bugs within a second"},
+			AchievementDailyRoutine: {"DAILY ROUTINE", "Play 7 daily challenges"},
+		},
+	},
+	LanguageSpanish: {
+		LanguageName:        "ESPAÑOL",
+		Usage:               []string{"[ARRASTRA] Apunta", "[SUELTA] Dispara"},
+		Credits:             []string{"CREADOR: NAOKI TSUJIO", "FUENTE: Press Start 2P by CodeMan38", "EFECTOS: MaouDamashii"},
+		DragMe:              "¡Arrástrame!",
+		GameOver:            "FIN",
+		YourScoreIs:         "TU PUNTUACIÓN ES",
+		Settings:            "AJUSTES",
+		Back:                "VOLVER",
+		On:                  "SÍ",
+		Off:                 "NO",
+		BGMVolume:           "VOL. MÚSICA",
+		SFXVolume:           "VOL. EFECTOS",
+		Mute:                "SILENCIO",
+		AimLine:             "LÍNEA",
+		Sight:               "MIRA",
+		SightNormal:         "NORMAL",
+		SightAssisted:       "ASISTIDA",
+		SightHidden:         "OCULTA",
+		Controls:            "CONTROL",
+		ControlPull:         "TIRAR",
+		ControlPush:         "EMPUJAR",
+		Language:            "IDIOMA",
+		ScreenShake:         "TEMBLOR",
+		Widescreen:          "PANORÁMICA",
+		Scaling:             "ESCALADO",
+		ScalingPixel:        "PÍXEL",
+		ScalingSmooth:       "SUAVE",
+		Demo:                "DEMO",
+		DifficultyEasy:      "FÁCIL",
+		DifficultyNormal:    "NORMAL",
+		DifficultyHard:      "DIFÍCIL",
+		Achievements:        "LOGROS",
+		Daily:               "DIARIO",
+		Results:             "RESULTADOS",
+		Shots:               "DISPAROS",
+		Hits:                "ACIERTOS",
+		Accuracy:            "PRECISIÓN",
+		LongestCombo:        "MEJOR COMBO",
+		FastestKill:         "CAZA MÁS RÁPIDA",
+		AchievementUnlocked: "DESBLOQUEADO",
+		AchievementTexts: map[AchievementID]AchievementText{
+			AchievementShyHunter:    {"CAZATÍMIDOS", "Acierta 10 bichos tímidos en una ronda"},
+			AchievementCentury:      {"CENTENARIO", "Consigue 100 puntos en una ronda"},
+			AchievementSharpshooter: {"FRANCOTIRADOR", "Acaba con un 90% de acierto (10+)"},
+			AchievementMultiKill:    {"MÚLTIPLE", "Derriba 3 bichos en un segundo"},
+			AchievementDailyRoutine: {"RUTINA DIARIA", "Juega 7 retos diarios"},
+		},
+	},
+}
+
+func (g *Game) messages() *Messages {
+	if m, ok := messageCatalog[g.settings.Language]; ok {
+		return m
+	}
+	return messageCatalog[LanguageEnglish]
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/tsujio/game-util/resourceutil"
+)
+
+type MusicLayer int
+
+const (
+	MusicLayerBase MusicLayer = iota
+	MusicLayerDrums
+	MusicLayerPulse
+)
+
+const (
+	musicSampleRate = 48000
+	musicBPM        = 120
+	// musicFadeTicks is the length of the crossfade between the layers
+	musicFadeTicks = 60
+)
+
+type musicTrack struct {
+	data         []byte
+	gain, target float64
+}
+
+// MusicMixer mixes the layers of the BGM, which loop in sync, and the
+// results jingle. The gains of the tracks are eased toward their targets
+// by Update, so that the layers fade in and out.
+type MusicMixer struct {
+	mu        sync.Mutex
+	layers    map[MusicLayer]*musicTrack
+	jingle    *musicTrack
+	loopSize  int64
+	pos       int64
+	jinglePos int64
+}
+
+// newMusicMixer loads the layers of the BGM from resources/bgm-*.wav. The
+// layers other than the base one fall back to synthesized ones when not
+// embedded.
+func newMusicMixer(context *audio.Context) *MusicMixer {
+	base := loadMusicData(context, "resources/bgm-archerfish.wav")
+	loopFrames := len(base) / bytesPerStereoFrame
+
+	loadOrSynthesize := func(path string, synthesize func(frames int) []byte) []byte {
+		if data, err := tryLoadMusicData(context, path); err == nil {
+			return data
+		}
+		return synthesize(loopFrames)
+	}
+
+	return &MusicMixer{
+		layers: map[MusicLayer]*musicTrack{
+			MusicLayerBase:  {data: base, gain: 1, target: 1},
+			MusicLayerDrums: {data: loadOrSynthesize("resources/bgm-archerfish-drums.wav", synthesizeDrums)},
+			MusicLayerPulse: {data: loadOrSynthesize("resources/bgm-archerfish-pulse.wav", synthesizePulse)},
+		},
+		jingle: &musicTrack{
+			data: loadOrSynthesize("resources/bgm-results.wav", func(int) []byte {
+				return synthesizeJingle()
+			}),
+		},
+		loopSize: int64(len(base)),
+	}
+}
+
+func tryLoadMusicData(context *audio.Context, path string) ([]byte, error) {
+	stream, err := resourceutil.LoadAudioStream(resources, path, context)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(stream)
+}
+
+func loadMusicData(context *audio.Context, path string) []byte {
+	data, err := tryLoadMusicData(context, path)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// Reset restarts the loop only with the base layer.
+func (m *MusicMixer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for layer, t := range m.layers {
+		if layer == MusicLayerBase {
+			t.gain, t.target = 1, 1
+		} else {
+			t.gain, t.target = 0, 0
+		}
+	}
+	m.jingle.gain, m.jingle.target = 0, 0
+	m.pos = 0
+	m.jinglePos = 0
+}
+
+func (m *MusicMixer) SetLayer(layer MusicLayer, on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if on {
+		m.layers[layer].target = 1
+	} else {
+		m.layers[layer].target = 0
+	}
+}
+
+// PlayJingle crossfades the layers to the jingle.
+func (m *MusicMixer) PlayJingle() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.layers {
+		t.target = 0
+	}
+	m.jingle.target = 1
+	m.jinglePos = 0
+}
+
+func (m *MusicMixer) Update() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	step := 1.0 / musicFadeTicks
+	for _, t := range append([]*musicTrack{m.jingle}, m.tracks()...) {
+		if t.gain < t.target {
+			t.gain = math.Min(t.gain+step, t.target)
+		} else if t.gain > t.target {
+			t.gain = math.Max(t.gain-step, t.target)
+		}
+	}
+}
+
+func (m *MusicMixer) tracks() []*musicTrack {
+	return []*musicTrack{m.layers[MusicLayerBase], m.layers[MusicLayerDrums], m.layers[MusicLayerPulse]}
+}
+
+func readSample(data []byte, i int64) float64 {
+	return float64(int16(uint16(data[i]) | uint16(data[i+1])<<8))
+}
+
+func (m *MusicMixer) Read(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(p) / bytesPerStereoFrame * bytesPerStereoFrame
+	tracks := m.tracks()
+	for i := 0; i < n; i += 2 {
+		var v float64
+		for _, t := range tracks {
+			if t.gain > 0 && m.pos < int64(len(t.data)) {
+				v += readSample(t.data, m.pos) * t.gain
+			}
+		}
+		if m.jingle.gain > 0 && m.jinglePos < int64(len(m.jingle.data)) {
+			v += readSample(m.jingle.data, m.jinglePos) * m.jingle.gain
+		}
+
+		u := uint16(int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, v))))
+		p[i] = byte(u)
+		p[i+1] = byte(u >> 8)
+
+		m.pos = (m.pos + 2) % m.loopSize
+		if m.jingle.gain > 0 {
+			m.jinglePos += 2
+		}
+	}
+
+	return n, nil
+}
+
+func (m *MusicMixer) Seek(offset int64, whence int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = m.pos + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	m.pos = pos / bytesPerStereoFrame * bytesPerStereoFrame % m.loopSize
+
+	return m.pos, nil
+}
+
+// pcmWriter builds 16-bit stereo PCM by adding up the voices.
+type pcmWriter struct {
+	samples []float64
+}
+
+func newPCMWriter(frames int) *pcmWriter {
+	return &pcmWriter{samples: make([]float64, frames)}
+}
+
+func (w *pcmWriter) add(startSec float64, duration float64, wave func(t float64) float64) {
+	start := int(startSec * musicSampleRate)
+	for i := 0; i < int(duration*musicSampleRate) && start+i < len(w.samples); i++ {
+		w.samples[start+i] += wave(float64(i) / musicSampleRate)
+	}
+}
+
+func (w *pcmWriter) bytes() []byte {
+	data := make([]byte, len(w.samples)*bytesPerStereoFrame)
+	for i, s := range w.samples {
+		u := uint16(int16(math.Max(-1, math.Min(1, s)) * math.MaxInt16))
+		for ch := 0; ch < 2; ch++ {
+			data[i*bytesPerStereoFrame+ch*2] = byte(u)
+			data[i*bytesPerStereoFrame+ch*2+1] = byte(u >> 8)
+		}
+	}
+	return data
+}
+
+func square(freq, t float64) float64 {
+	if math.Mod(t*freq, 1) < 0.5 {
+		return 1
+	}
+	return -1
+}
+
+func triangle(freq, t float64) float64 {
+	return 4*math.Abs(math.Mod(t*freq, 1)-0.5) - 1
+}
+
+func synthesizeDrums(frames int) []byte {
+	w := newPCMWriter(frames)
+	beat := 60.0 / musicBPM
+	noise := uint32(1)
+	for t := 0.0; t < float64(frames)/musicSampleRate; t += beat {
+		// Kick on the beats
+		w.add(t, 0.15, func(t float64) float64 {
+			freq := 50 + 70*math.Exp(-t*30)
+			return 0.35 * math.Sin(2*math.Pi*freq*t) * math.Exp(-t*20)
+		})
+		// Hi-hat on the offbeats
+		w.add(t+beat/2, 0.04, func(t float64) float64 {
+			noise ^= noise << 13
+			noise ^= noise >> 17
+			noise ^= noise << 5
+			return 0.08 * (float64(noise)/math.MaxUint32*2 - 1) * math.Exp(-t*80)
+		})
+	}
+	return w.bytes()
+}
+
+func synthesizePulse(frames int) []byte {
+	w := newPCMWriter(frames)
+	step := 60.0 / musicBPM / 4
+	for i := 0; float64(i)*step < float64(frames)/musicSampleRate; i++ {
+		freq := 880.0
+		if i%4 != 0 {
+			freq = 660
+		}
+		w.add(float64(i)*step, 0.05, func(t float64) float64 {
+			return 0.05 * square(freq, t) * math.Exp(-t*60)
+		})
+	}
+	return w.bytes()
+}
+
+func synthesizeJingle() []byte {
+	notes := []float64{523.25, 659.25, 783.99, 1046.50}
+	noteLen := 0.15
+	holdLen := 1.2
+	w := newPCMWriter(int((noteLen*float64(len(notes)) + holdLen) * musicSampleRate))
+	for i, freq := range notes {
+		freq := freq
+		w.add(float64(i)*noteLen, noteLen, func(t float64) float64 {
+			return 0.2 * triangle(freq, t)
+		})
+	}
+	for _, freq := range notes[1:] {
+		freq := freq
+		w.add(float64(len(notes))*noteLen, holdLen, func(t float64) float64 {
+			return 0.12 * triangle(freq, t) * math.Exp(-t*2.5)
+		})
+	}
+	return w.bytes()
+}
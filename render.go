@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type RenderLayer int
+
+const (
+	RenderLayerBackground RenderLayer = iota
+	RenderLayerWorld
+	RenderLayerOverlay
+	RenderLayerHUD
+)
+
+type renderItem struct {
+	layer RenderLayer
+	z     float64
+	draw  func(screen *ebiten.Image)
+}
+
+// RenderQueue collects draw calls during a frame and issues them in depth
+// order. Items are sorted by layer first, then from far to near. Items
+// with equal layer and z are drawn in submission order.
+type RenderQueue struct {
+	items []renderItem
+}
+
+func (q *RenderQueue) Submit(layer RenderLayer, z float64, draw func(screen *ebiten.Image)) {
+	q.items = append(q.items, renderItem{
+		layer: layer,
+		z:     z,
+		draw:  draw,
+	})
+}
+
+// Flush draws the queued items onto the image which target returns for
+// each layer.
+func (q *RenderQueue) Flush(target func(layer RenderLayer) *ebiten.Image) {
+	sort.SliceStable(q.items, func(i, j int) bool {
+		if q.items[i].layer != q.items[j].layer {
+			return q.items[i].layer < q.items[j].layer
+		}
+		return q.items[i].z > q.items[j].z
+	})
+
+	for i := range q.items {
+		q.items[i].draw(target(q.items[i].layer))
+	}
+
+	for i := range q.items {
+		q.items[i].draw = nil
+	}
+	q.items = q.items[:0]
+}
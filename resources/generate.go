@@ -1,20 +1,149 @@
-package main
-
-import (
-	"os"
-
-	"github.com/hajimehoshi/ebiten/v2/audio"
-	"github.com/tsujio/game-util/resourceutil"
-)
-
-//go:generate go run generate.go "魔王魂 効果音 システム49.mp3"
-//go:generate go run generate.go "魔王魂 効果音 システム16.mp3"
-//go:generate go run generate.go "魔王魂  水02.mp3"
-//go:generate go run generate.go "魔王魂 効果音 笛01.mp3"
-//go:generate go run generate.go "魔王魂 効果音 点火01.mp3"
-//go:generate go run generate.go "魔王魂 効果音 物音15.mp3"
-//go:generate go run generate.go "魔王魂 効果音 システム46.mp3"
-
-func main() {
-	resourceutil.ForceSaveDecodedAudio(os.Args[1], audio.NewContext(48000))
-}
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/tsujio/game-util/resourceutil"
+)
+
+//go:generate go run generate.go -manifest sounds.json -o ../sounds_gen.go
+
+var audioExtensions = map[string]bool{
+	".mp3": true,
+	".ogg": true,
+	".wav": true,
+}
+
+// isSoundEffect reports whether the file is a sound effect, which is
+// decoded in advance. BGM files (bgm-*) are decoded while playing.
+func isSoundEffect(name string) bool {
+	return audioExtensions[strings.ToLower(filepath.Ext(name))] && !strings.HasPrefix(name, "bgm-")
+}
+
+type sound struct {
+	Name string
+	File string
+}
+
+// loadManifest reads the mapping from logical names to the audio files,
+// and checks that it matches the audio files in the directory.
+func loadManifest(path string) ([]sound, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	for _, e := range entries {
+		if !e.IsDir() && isSoundEffect(e.Name()) {
+			files[e.Name()] = true
+		}
+	}
+
+	var sounds []sound
+	registered := make(map[string]bool)
+	for name, file := range manifest {
+		if !token.IsIdentifier(name) || !token.IsExported(name) {
+			return nil, fmt.Errorf("%s: invalid sound name %q", path, name)
+		}
+		if !files[file] {
+			return nil, fmt.Errorf("%s: %s: audio file %q not found", path, name, file)
+		}
+		registered[file] = true
+		sounds = append(sounds, sound{Name: name, File: file})
+	}
+	for file := range files {
+		if !registered[file] {
+			return nil, fmt.Errorf("%s: audio file %q is not registered", path, file)
+		}
+	}
+
+	sort.Slice(sounds, func(i, j int) bool {
+		return sounds[i].Name < sounds[j].Name
+	})
+
+	return sounds, nil
+}
+
+var registryTemplate = template.Must(template.New("registry").Parse(`// Code generated by resources/generate.go; DO NOT EDIT.
+
+package main
+
+type SoundID int
+
+// sfx is the registry of the sound effects.
+var sfx = struct {
+{{- range .}}
+	{{.Name}} SoundID
+{{- end}}
+}{
+{{- range $i, $s := .}}
+	{{$s.Name}}: {{$i}},
+{{- end}}
+}
+
+// soundFiles maps the sound effects to the decoded audio files.
+var soundFiles = map[SoundID]string{
+{{- range .}}
+	sfx.{{.Name}}: {{printf "%q" (printf "resources/%s.dat" .File)}},
+{{- end}}
+}
+`))
+
+func generateRegistry(sounds []sound) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := registryTemplate.Execute(&buf, sounds); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+func run(manifestPath, outputPath string) error {
+	sounds, err := loadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	audioContext := audio.NewContext(48000)
+	for _, s := range sounds {
+		if err := resourceutil.SaveDecodedAudio(s.File, audioContext); err != nil {
+			return fmt.Errorf("%s: %w", s.File, err)
+		}
+	}
+
+	src, err := generateRegistry(sounds)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, src, 0644)
+}
+
+func main() {
+	manifestPath := flag.String("manifest", "sounds.json", "mapping from sound names to audio files")
+	outputPath := flag.String("o", "../sounds_gen.go", "output Go file of the sound registry")
+	flag.Parse()
+
+	if err := run(*manifestPath, *outputPath); err != nil {
+		fmt.Fprintln(os.Stderr, "generate:", err)
+		os.Exit(1)
+	}
+}
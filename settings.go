@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/tsujio/game-archerfish/core"
+)
+
+type ControlScheme string
+
+const (
+	// ControlSchemePull shoots to the opposite side of the drag like a
+	// slingshot.
+	ControlSchemePull ControlScheme = "pull"
+	// ControlSchemePush shoots to the side of the drag.
+	ControlSchemePush ControlScheme = "push"
+)
+
+type SightMode string
+
+const (
+	// SightModeNormal shows where the bullet lands.
+	SightModeNormal SightMode = "normal"
+	// SightModeAssisted also shows the arc of the bullet and the enemy it
+	// would hit.
+	SightModeAssisted SightMode = "assisted"
+	// SightModeHidden shows no prediction.
+	SightModeHidden SightMode = "hidden"
+)
+
+var sightModes = []SightMode{SightModeNormal, SightModeAssisted, SightModeHidden}
+
+type Settings struct {
+	BGMVolume     float64         `json:"bgm_volume"`
+	SFXVolume     float64         `json:"sfx_volume"`
+	Mute          bool            `json:"mute"`
+	AimLine       bool            `json:"aim_line"`
+	ControlScheme ControlScheme   `json:"control_scheme"`
+	Language      Language        `json:"language"`
+	ScreenShake   bool            `json:"screen_shake"`
+	Widescreen    bool            `json:"widescreen"`
+	ScalingMode   ScalingMode     `json:"scaling_mode"`
+	SightMode     SightMode       `json:"sight_mode"`
+	Difficulty    core.Difficulty `json:"difficulty"`
+}
+
+func defaultSettings() *Settings {
+	return &Settings{
+		BGMVolume:     1,
+		SFXVolume:     1,
+		AimLine:       true,
+		ControlScheme: ControlSchemePull,
+		Language:      LanguageEnglish,
+		ScreenShake:   true,
+		ScalingMode:   ScalingModePixelPerfect,
+		SightMode:     SightModeNormal,
+		Difficulty:    core.DifficultyNormal,
+	}
+}
+
+// loadSettings reads the saved settings. The defaults are used when nothing
+// is saved yet.
+func loadSettings() (*Settings, error) {
+	data, err := loadData("settings")
+	if err != nil {
+		return defaultSettings(), nil
+	}
+	s, err := parseSettings(data)
+	if err != nil {
+		return s, fmt.Errorf("settings: %w", err)
+	}
+	return s, nil
+}
+
+// parseSettings reads the settings in JSON. The defaults are used for the
+// missing fields and the invalid ones. If the data is broken, the fields
+// read before the error are kept with the error.
+func parseSettings(data []byte) (*Settings, error) {
+	s := defaultSettings()
+	err := decodeSettings(data, s)
+
+	// An unknown difficulty would be played as normal but ranked apart
+	if _, ok := core.ParseDifficulty(string(s.Difficulty)); !ok {
+		if err == nil {
+			err = fmt.Errorf("unknown difficulty %q", s.Difficulty)
+		}
+		s.Difficulty = core.DifficultyNormal
+	}
+
+	return s, err
+}
+
+// decodeSettings decodes the fields of the JSON object one by one into s,
+// so that a field of a wrong type or a truncated file loses only the
+// fields it spoils. The first error is returned.
+func decodeSettings(data []byte, s *Settings) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return fmt.Errorf("not an object")
+	}
+
+	var firstErr error
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+
+		field, err := json.Marshal(map[string]json.RawMessage{t.(string): value})
+		if err == nil {
+			err = json.Unmarshal(field, s)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+func saveSettings(s *Settings) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return saveData("settings", data)
+}
+
+// storeSettings saves the current settings, with a toast if it fails.
+func (g *Game) storeSettings() {
+	if !g.persistSettings {
+		return
+	}
+	if err := saveSettings(g.settings); err != nil {
+		g.showToast(fmt.Sprintf("settings: %v", err), toastColorError)
+	}
+}
+
+const (
+	settingsItemX      = 60
+	settingsValueX     = 380
+	settingsItemY      = 90
+	settingsItemHeight = 34
+	settingsBackY      = 440
+)
+
+type settingsItem struct {
+	label  string
+	value  string
+	change func(delta int)
+}
+
+func cycleBool(v *bool) func(int) {
+	return func(int) {
+		*v = !*v
+	}
+}
+
+func stepVolume(v *float64) func(int) {
+	return func(delta int) {
+		*v = math.Round(math.Max(0, math.Min(1, *v+float64(delta)*0.1))*10) / 10
+	}
+}
+
+func (g *Game) settingsItems() []settingsItem {
+	s := g.settings
+	m := g.messages()
+
+	onOff := func(v bool) string {
+		if v {
+			return m.On
+		}
+		return m.Off
+	}
+
+	volume := func(v float64) string {
+		return fmt.Sprintf("%d", int(math.Round(v*10)))
+	}
+
+	controls := m.ControlPull
+	if s.ControlScheme == ControlSchemePush {
+		controls = m.ControlPush
+	}
+
+	sight := map[SightMode]string{
+		SightModeNormal:   m.SightNormal,
+		SightModeAssisted: m.SightAssisted,
+		SightModeHidden:   m.SightHidden,
+	}[s.SightMode]
+
+	scaling := m.ScalingPixel
+	if s.ScalingMode == ScalingModeSmooth {
+		scaling = m.ScalingSmooth
+	}
+
+	return []settingsItem{
+		{m.BGMVolume, volume(s.BGMVolume), stepVolume(&s.BGMVolume)},
+		{m.SFXVolume, volume(s.SFXVolume), stepVolume(&s.SFXVolume)},
+		{m.Mute, onOff(s.Mute), cycleBool(&s.Mute)},
+		{m.AimLine, onOff(s.AimLine), cycleBool(&s.AimLine)},
+		{m.Sight, sight, func(delta int) {
+			i := 0
+			for j, mode := range sightModes {
+				if mode == s.SightMode {
+					i = j
+				}
+			}
+			s.SightMode = sightModes[(i+delta+len(sightModes))%len(sightModes)]
+		}},
+		{m.Controls, controls, func(int) {
+			if s.ControlScheme == ControlSchemePush {
+				s.ControlScheme = ControlSchemePull
+			} else {
+				s.ControlScheme = ControlSchemePush
+			}
+		}},
+		{m.Language, messageCatalog[s.Language].LanguageName, func(delta int) {
+			i := 0
+			for j, l := range languages {
+				if l == s.Language {
+					i = j
+				}
+			}
+			s.Language = languages[(i+delta+len(languages))%len(languages)]
+		}},
+		{m.ScreenShake, onOff(s.ScreenShake), cycleBool(&s.ScreenShake)},
+		{m.Widescreen, onOff(s.Widescreen), func(int) {
+			s.Widescreen = !s.Widescreen
+			if s.Widescreen {
+				ebiten.SetWindowSize(wideScreenWidth, screenHeight)
+			} else {
+				ebiten.SetWindowSize(standardScreenWidth, screenHeight)
+			}
+		}},
+		{m.Scaling, scaling, func(int) {
+			if s.ScalingMode == ScalingModeSmooth {
+				s.ScalingMode = ScalingModePixelPerfect
+			} else {
+				s.ScalingMode = ScalingModeSmooth
+			}
+		}},
+	}
+}
+
+func (g *Game) isSettingsButtonTouched() bool {
+	pos := g.getTouchPosition()
+	w := textLen(g.messages().Settings) * int(fontS.FaceOptions.Size)
+	return pos.X >= g.screenWidth-w-20 && pos.X < g.screenWidth &&
+		pos.Y >= 0 && pos.Y < 35
+}
+
+func (g *Game) drawSettingsButton(screen *ebiten.Image) {
+	t := g.messages().Settings
+	text.Draw(screen, t, fontS.Face, g.screenWidth-(textLen(t)+1)*int(fontS.FaceOptions.Size), 25, color.White)
+}
+
+func (g *Game) updateSettings() {
+	if !g.isJustTouchedOnField() {
+		return
+	}
+
+	pos := g.getTouchPosition()
+
+	if pos.Y >= settingsBackY-30 {
+		g.storeSettings()
+		g.applySettings()
+		g.initialize()
+		return
+	}
+
+	i := (pos.Y - settingsItemY + settingsItemHeight*2/3) / settingsItemHeight
+	items := g.settingsItems()
+	if pos.Y < settingsItemY-settingsItemHeight*2/3 || i >= len(items) {
+		return
+	}
+
+	delta := 1
+	if pos.X < settingsValueX+60 {
+		delta = -1
+	}
+	items[i].change(delta)
+
+	g.applySettings()
+}
+
+// applySettings reflects the settings on the parts out of the game state.
+func (g *Game) applySettings() {
+	if g.settings.Mute {
+		g.sound.SetBusVolume(BusMaster, 0)
+	} else {
+		g.sound.SetBusVolume(BusMaster, 1)
+	}
+	g.sound.SetBusVolume(BusMusic, g.settings.BGMVolume)
+	g.sound.SetBusVolume(BusSFX, g.settings.SFXVolume)
+	g.viewport.mode = g.settings.ScalingMode
+}
+
+func (g *Game) drawSettings(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 20, 20, float64(g.screenWidth)-20*2, screenHeight-20*2, color.RGBA{0, 0, 0, 0xa0})
+
+	t := g.messages().Settings
+	text.Draw(screen, t, fontM.Face, g.screenWidth/2-textLen(t)*int(fontM.FaceOptions.Size)/2, 60, color.White)
+
+	for i, item := range g.settingsItems() {
+		y := settingsItemY + i*settingsItemHeight
+		text.Draw(screen, item.label, fontS.Face, settingsItemX, y, color.White)
+		text.Draw(screen, "<", fontS.Face, settingsValueX, y, color.White)
+		text.Draw(screen, item.value, fontS.Face, settingsValueX+90-textLen(item.value)*int(fontS.FaceOptions.Size)/2, y, color.RGBA{0xff, 0xe0, 0, 0xff})
+		text.Draw(screen, ">", fontS.Face, settingsValueX+180, y, color.White)
+	}
+
+	t = g.messages().Back
+	text.Draw(screen, t, fontS.Face, g.screenWidth/2-textLen(t)*int(fontS.FaceOptions.Size)/2, settingsBackY, color.White)
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestParseSettings(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		data    string
+		want    func(s *Settings)
+		wantErr bool
+	}{
+		{
+			name: "complete",
+			data: `{"bgm_volume":0.5,"mute":true,"language":"es"}`,
+			want: func(s *Settings) {
+				s.BGMVolume, s.Mute, s.Language = 0.5, true, LanguageSpanish
+			},
+		},
+		{
+			name: "truncated",
+			data: `{"bgm_volume":0.5,"mute":true,"language":"e`,
+			want: func(s *Settings) {
+				s.BGMVolume, s.Mute = 0.5, true
+			},
+			wantErr: true,
+		},
+		{
+			name: "field of a wrong type",
+			data: `{"bgm_volume":"loud","mute":true}`,
+			want: func(s *Settings) {
+				s.Mute = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown difficulty",
+			data: `{"mute":true,"difficulty":"nightmare"}`,
+			want: func(s *Settings) {
+				s.Mute = true
+			},
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			data:    `[]`,
+			want:    func(s *Settings) {},
+			wantErr: true,
+		},
+	} {
+		s, err := parseSettings([]byte(c.data))
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+		want := defaultSettings()
+		c.want(want)
+		if *s != *want {
+			t.Errorf("%s: decoded %+v, want %+v", c.name, *s, *want)
+		}
+	}
+}
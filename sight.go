@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/geom"
+)
+
+const sightDotInterval = 3
+
+// drawAssistedSight draws the arc of the bullet as dots up to the landing
+// point, and marks the enemy the bullet would hit with the time to the
+// impact.
+func (g *Game) drawAssistedSight(screen *ebiten.Image, bullet core.Bullet, landingTicks float64) {
+	p := geom.Vec3{X: bullet.X, Y: bullet.Y, Z: bullet.Z}
+	v := geom.Vec3{X: bullet.VX, Y: bullet.VY, Z: bullet.VZ}
+
+	i, ticks, hit := g.world.PredictHit(bullet)
+	end := landingTicks
+	if hit && float64(ticks) < end {
+		end = float64(ticks)
+	}
+
+	for t := float64(sightDotInterval); t < end; t += sightDotInterval {
+		q := geom.PositionAfter(p, v, g.world.Tuning.Gravity, t)
+		x, y := g.camera.ToScreenPosition(q.X, q.Y, q.Z)
+		ebitenutil.DrawRect(screen, x-1, y-1, 2, 2, color.RGBA{0xff, 0xff, 0xff, 0xa0})
+	}
+
+	if !hit {
+		return
+	}
+
+	e := &g.world.Enemies[i]
+	x, y := g.camera.ToScreenPosition(e.X+e.VX*float64(ticks), e.Y, e.Z)
+	r := (e.R + bullet.R) * g.camera.Scale(e.Z)
+	ebitenutil.DrawCircle(screen, x, y, r, color.RGBA{0xff, 0xe0, 0, 0x50})
+
+	t := fmt.Sprintf("%.1fs", float64(ticks)/60)
+	text.Draw(screen, t, fontS.Face, int(x+r), int(y-r), color.RGBA{0xff, 0xe0, 0, 0xff})
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/tsujio/game-util/resourceutil"
+)
+
+type Bus int
+
+const (
+	BusMaster Bus = iota
+	BusMusic
+	BusSFX
+)
+
+type soundDefinition struct {
+	// maxVoices is the number of the sounds which can play at the same
+	// time. The oldest one is restarted when exceeded.
+	maxVoices int
+	volume    float64
+	// ducksMusic lowers the music while the sound is playing.
+	ducksMusic bool
+}
+
+var defaultSoundDefinition = soundDefinition{maxVoices: 1, volume: 1}
+
+// soundDefinitions holds the sounds which are played differently from the
+// default. The sounds themselves are registered in resources/sounds.json.
+var soundDefinitions = map[SoundID]soundDefinition{
+	sfx.Splash:   {maxVoices: 3, volume: 0.8},
+	sfx.Shoot:    {maxVoices: 3, volume: 1},
+	sfx.Hit:      {maxVoices: 4, volume: 1},
+	sfx.GameOver: {maxVoices: 1, volume: 1, ducksMusic: true},
+}
+
+const (
+	duckedMusicGain = 0.3
+	duckSpeed       = 0.05
+)
+
+type soundVoice struct {
+	player *audio.Player
+	stream *StereoStream
+}
+
+type soundBank struct {
+	definition soundDefinition
+	data       []byte
+	voices     []*soundVoice
+}
+
+// SoundManager owns all the sounds of the game. Sound effects are played
+// by the players pooled per sound, and mixed on the buses.
+type SoundManager struct {
+	context   *audio.Context
+	banks     map[SoundID]*soundBank
+	mixer     *MusicMixer
+	music     *audio.Player
+	busVolume map[Bus]float64
+	musicGain float64
+}
+
+func newSoundManager(context *audio.Context) *SoundManager {
+	mixer := newMusicMixer(context)
+	music, err := audio.NewPlayer(context, mixer)
+	if err != nil {
+		panic(err)
+	}
+
+	m := &SoundManager{
+		context: context,
+		banks:   make(map[SoundID]*soundBank),
+		mixer:   mixer,
+		music:   music,
+		busVolume: map[Bus]float64{
+			BusMaster: 1,
+			BusMusic:  1,
+			BusSFX:    1,
+		},
+		musicGain: 1,
+	}
+	for id, path := range soundFiles {
+		def, ok := soundDefinitions[id]
+		if !ok {
+			def = defaultSoundDefinition
+		}
+		m.banks[id] = &soundBank{
+			definition: def,
+			data:       resourceutil.ForceLoadDecodedAudio(resources, path, context),
+		}
+	}
+	return m
+}
+
+func (m *SoundManager) SetBusVolume(bus Bus, volume float64) {
+	m.busVolume[bus] = volume
+	m.updateMusicVolume()
+}
+
+func (m *SoundManager) busGain(bus Bus) float64 {
+	return m.busVolume[BusMaster] * m.busVolume[bus]
+}
+
+func (m *SoundManager) updateMusicVolume() {
+	m.music.SetVolume(m.busGain(BusMusic) * m.musicGain)
+}
+
+func (m *SoundManager) Play(id SoundID) {
+	m.PlayAt(id, 0, 1)
+}
+
+// PlayAt plays the sound at the position in the stereo field from -1
+// (left) to 1 (right), attenuated by the gain.
+func (m *SoundManager) PlayAt(id SoundID, pan, gain float64) {
+	bank := m.banks[id]
+
+	var voice *soundVoice
+	for _, v := range bank.voices {
+		if !v.player.IsPlaying() {
+			voice = v
+			break
+		}
+	}
+	if voice == nil {
+		if len(bank.voices) < bank.definition.maxVoices {
+			stream := newStereoStream(bank.data)
+			player, err := audio.NewPlayer(m.context, stream)
+			if err != nil {
+				return
+			}
+			voice = &soundVoice{
+				player: player,
+				stream: stream,
+			}
+			bank.voices = append(bank.voices, voice)
+		} else {
+			// Steal the voice which has played longest
+			voice = bank.voices[0]
+			for _, v := range bank.voices[1:] {
+				if v.player.Current() > voice.player.Current() {
+					voice = v
+				}
+			}
+		}
+	}
+
+	voice.player.Pause()
+	voice.player.Rewind()
+	voice.stream.SetPan(pan, gain)
+	voice.player.SetVolume(bank.definition.volume * m.busGain(BusSFX))
+	voice.player.Play()
+}
+
+// PlayMusic plays the BGM from the start only with the base layer.
+func (m *SoundManager) PlayMusic() {
+	m.mixer.Reset()
+	m.music.Rewind()
+	m.music.Play()
+}
+
+func (m *SoundManager) SetMusicLayer(layer MusicLayer, on bool) {
+	m.mixer.SetLayer(layer, on)
+}
+
+// PlayResultsJingle crossfades the BGM to the results jingle.
+func (m *SoundManager) PlayResultsJingle() {
+	m.mixer.PlayJingle()
+}
+
+func (m *SoundManager) PauseMusic() {
+	m.music.Pause()
+}
+
+// Update eases the music volume for ducking and the layers of the music.
+// It should be called every tick.
+func (m *SoundManager) Update() {
+	m.mixer.Update()
+
+	target := 1.0
+	for _, bank := range m.banks {
+		if !bank.definition.ducksMusic {
+			continue
+		}
+		for _, v := range bank.voices {
+			if v.player.IsPlaying() {
+				target = duckedMusicGain
+			}
+		}
+	}
+
+	switch {
+	case m.musicGain > target:
+		m.musicGain -= duckSpeed
+		if m.musicGain < target {
+			m.musicGain = target
+		}
+	case m.musicGain < target:
+		m.musicGain += duckSpeed / 4
+		if m.musicGain > target {
+			m.musicGain = target
+		}
+	}
+
+	m.updateMusicVolume()
+}
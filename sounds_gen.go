@@ -0,0 +1,35 @@
+// Code generated by resources/generate.go; DO NOT EDIT.
+
+package main
+
+type SoundID int
+
+// sfx is the registry of the sound effects.
+var sfx = struct {
+	GameOver  SoundID
+	GameStart SoundID
+	Hit       SoundID
+	Ranking   SoundID
+	Shoot     SoundID
+	Splash    SoundID
+	TimeStart SoundID
+}{
+	GameOver:  0,
+	GameStart: 1,
+	Hit:       2,
+	Ranking:   3,
+	Shoot:     4,
+	Splash:    5,
+	TimeStart: 6,
+}
+
+// soundFiles maps the sound effects to the decoded audio files.
+var soundFiles = map[SoundID]string{
+	sfx.GameOver:  "resources/魔王魂 効果音 物音15.mp3.dat",
+	sfx.GameStart: "resources/魔王魂 効果音 システム49.mp3.dat",
+	sfx.Hit:       "resources/魔王魂 効果音 システム16.mp3.dat",
+	sfx.Ranking:   "resources/魔王魂 効果音 システム46.mp3.dat",
+	sfx.Shoot:     "resources/魔王魂 効果音 点火01.mp3.dat",
+	sfx.Splash:    "resources/魔王魂  水02.mp3.dat",
+	sfx.TimeStart: "resources/魔王魂 効果音 笛01.mp3.dat",
+}
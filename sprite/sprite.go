@@ -0,0 +1,215 @@
+// Package sprite reads sprites written in a small ASCII format.
+//
+// A sprite file starts with a header, which maps characters to colors,
+// followed by a line of "---" and the frames separated by blank lines:
+//
+//	// Comment
+//	dot 3
+//	# 000000
+//	. ffffffff
+//	---
+//	 ##
+//	#.#
+//
+//	 ##
+//	###
+//
+// Colors are written as RRGGBB or RRGGBBAA in hex. Spaces in frames are
+// transparent. The header may have "dot N" for the size of a dot and
+// "size WxH" for the size the frames must fit in. Without size, it is the
+// size of the largest frame. Rows shorter than the width are padded with
+// spaces, but the frames keep their own heights, as a row of spaces would
+// end the frame. A transparent color in the palette makes an empty row.
+package sprite
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Sprite struct {
+	Palette       map[rune]color.RGBA
+	DotSize       float64
+	Width, Height int
+	// Frames are the rows of the frames, each of which is Width long. The
+	// frames may be of different heights, up to Height.
+	Frames [][][]rune
+}
+
+// ColorMap returns the palette in the form which drawutil accepts.
+func (s *Sprite) ColorMap() map[rune]color.Color {
+	m := make(map[rune]color.Color, len(s.Palette))
+	for c, rgba := range s.Palette {
+		m[c] = rgba
+	}
+	return m
+}
+
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+func parseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{b[0], b[1], b[2], b[3]}, nil
+}
+
+// Parse reads a sprite and checks that all the frames fit in the size and
+// use only the characters in the palette.
+func Parse(r io.Reader) (*Sprite, error) {
+	s := &Sprite{
+		Palette: make(map[rune]color.RGBA),
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	inHeader := true
+	var frame []string
+	frameLine := 0
+
+	type rawFrame struct {
+		rows []string
+		line int
+	}
+	var frames []rawFrame
+	flush := func() {
+		if len(frame) > 0 {
+			frames = append(frames, rawFrame{frame, frameLine})
+			frame = nil
+		}
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if inHeader {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case trimmed == "" || strings.HasPrefix(trimmed, "//"):
+			case trimmed == "---":
+				inHeader = false
+			case strings.HasPrefix(trimmed, "dot "):
+				v, err := strconv.ParseFloat(strings.TrimSpace(trimmed[4:]), 64)
+				if err != nil || v <= 0 {
+					return nil, &ParseError{lineNum, fmt.Sprintf("invalid dot size %q", trimmed[4:])}
+				}
+				s.DotSize = v
+			case strings.HasPrefix(trimmed, "size "):
+				var w, h int
+				if _, err := fmt.Sscanf(trimmed[5:], "%dx%d", &w, &h); err != nil || w <= 0 || h <= 0 {
+					return nil, &ParseError{lineNum, fmt.Sprintf("invalid size %q", trimmed[5:])}
+				}
+				s.Width, s.Height = w, h
+			default:
+				fields := strings.Fields(trimmed)
+				if len(fields) != 2 || utf8.RuneCountInString(fields[0]) != 1 {
+					return nil, &ParseError{lineNum, fmt.Sprintf("invalid palette entry %q", trimmed)}
+				}
+				c, _ := utf8.DecodeRuneInString(fields[0])
+				rgba, err := parseColor(fields[1])
+				if err != nil {
+					return nil, &ParseError{lineNum, err.Error()}
+				}
+				if _, ok := s.Palette[c]; ok {
+					return nil, &ParseError{lineNum, fmt.Sprintf("duplicate palette entry %q", c)}
+				}
+				s.Palette[c] = rgba
+			}
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if len(frame) == 0 {
+			frameLine = lineNum
+		}
+		frame = append(frame, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if inHeader {
+		return nil, &ParseError{lineNum, `missing "---" after the header`}
+	}
+	if len(frames) == 0 {
+		return nil, &ParseError{lineNum, "no frames"}
+	}
+
+	if s.Width == 0 {
+		for _, f := range frames {
+			if w := frameWidth(f.rows); w > s.Width {
+				s.Width = w
+			}
+			if len(f.rows) > s.Height {
+				s.Height = len(f.rows)
+			}
+		}
+	}
+	for _, f := range frames {
+		rows, err := s.buildFrame(f.rows, f.line)
+		if err != nil {
+			return nil, err
+		}
+		s.Frames = append(s.Frames, rows)
+	}
+
+	return s, nil
+}
+
+func frameWidth(rows []string) int {
+	width := 0
+	for _, row := range rows {
+		if n := utf8.RuneCountInString(row); n > width {
+			width = n
+		}
+	}
+	return width
+}
+
+func (s *Sprite) buildFrame(rows []string, line int) ([][]rune, error) {
+	if width := frameWidth(rows); width > s.Width || len(rows) > s.Height {
+		return nil, &ParseError{line, fmt.Sprintf("frame size %dx%d exceeds %dx%d", width, len(rows), s.Width, s.Height)}
+	}
+
+	frame := make([][]rune, len(rows))
+	for i, row := range rows {
+		frame[i] = make([]rune, s.Width)
+		for j := range frame[i] {
+			frame[i][j] = ' '
+		}
+		for j, c := range []rune(row) {
+			if _, ok := s.Palette[c]; c != ' ' && !ok {
+				return nil, &ParseError{line + i, fmt.Sprintf("character %q is not in the palette", c)}
+			}
+			frame[i][j] = c
+		}
+	}
+
+	return frame, nil
+}
@@ -0,0 +1,63 @@
+package sprite
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFramesOfDifferentHeights(t *testing.T) {
+	s, err := Parse(strings.NewReader(`dot 2
+# 000000
+_ 00000000
+---
+ #
+###
+ #
+
+ #
+##
+
+_
+ #
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.DotSize != 2 || s.Width != 3 || s.Height != 3 {
+		t.Errorf("dot %v, size %dx%d, want dot 2, size 3x3", s.DotSize, s.Width, s.Height)
+	}
+	want := [][][]rune{
+		{[]rune(" # "), []rune("###"), []rune(" # ")},
+		{[]rune(" # "), []rune("## ")},
+		{[]rune("_  "), []rune(" # ")},
+	}
+	if !reflect.DeepEqual(s.Frames, want) {
+		t.Errorf("frames = %q, want %q", s.Frames, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, c := range []struct {
+		name, src string
+		line      int
+	}{
+		{"frame larger than the size", "size 2x2\n# 000000\n---\n##\n##\n\n##\n##\n##\n", 7},
+		{"frame wider than the size", "size 2x2\n# 000000\n---\n###\n", 4},
+		{"character not in the palette", "# 000000\n---\n#\n#o\n", 4},
+		{"invalid color", "# 00000\n---\n#\n", 1},
+		{"no frames", "# 000000\n---\n", 2},
+	} {
+		_, err := Parse(strings.NewReader(c.src))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: err = %v, want a ParseError", c.name, err)
+			continue
+		}
+		if perr.Line != c.line {
+			t.Errorf("%s: error at line %d, want %d: %v", c.name, perr.Line, c.line, perr)
+		}
+	}
+}
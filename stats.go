@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-util/drawutil"
+)
+
+// scoreSampleTicks is the interval of the score samples, the same as the
+// one of the "playing" logs.
+const scoreSampleTicks = 600
+
+// RoundStats is the statistics of a round, shown on the results screen.
+type RoundStats struct {
+	Shots        int
+	Hits         int
+	Kills        map[core.EnemyKind]int
+	LongestCombo int
+	// FastestKill is the least ticks from the spawn of an enemy to its hit,
+	// valid only if there are hits
+	FastestKill  uint64
+	ScoreSamples []ScoreSample
+}
+
+// ScoreSample is the score at the time of the round.
+type ScoreSample struct {
+	TimeInTicks uint64
+	Score       int
+}
+
+func newRoundStats() *RoundStats {
+	return &RoundStats{
+		Kills:        make(map[core.EnemyKind]int),
+		ScoreSamples: []ScoreSample{{}},
+	}
+}
+
+// Update collects what happened in the last update of the world.
+func (s *RoundStats) Update(w *core.World) {
+	for _, e := range w.Events {
+		switch e.Kind {
+		case core.EventShoot:
+			s.Shots++
+		case core.EventHit:
+			if s.Hits == 0 || e.Enemy.Ticks < s.FastestKill {
+				s.FastestKill = e.Enemy.Ticks
+			}
+			s.Hits++
+			s.Kills[e.Enemy.Kind]++
+		}
+	}
+
+	if w.Combo > s.LongestCombo {
+		s.LongestCombo = w.Combo
+	}
+}
+
+// SampleScore records the score of the world for the sparkline.
+func (s *RoundStats) SampleScore(w *core.World) {
+	s.ScoreSamples = append(s.ScoreSamples, ScoreSample{
+		TimeInTicks: w.TimeInTicks,
+		Score:       w.Score,
+	})
+}
+
+func (s *RoundStats) Accuracy() float64 {
+	if s.Shots == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Shots)
+}
+
+const (
+	resultsLabelX     = 80
+	resultsValueX     = 380
+	resultsItemY      = 110
+	resultsItemHeight = 28
+	sparklineWidth    = 240
+	sparklineHeight   = 50
+)
+
+func (g *Game) drawResults(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 20, 20, float64(g.screenWidth)-20*2, screenHeight-20*2, color.RGBA{0, 0, 0, 0xa0})
+
+	m := g.messages()
+	s := g.stats
+
+	t := m.Results
+	text.Draw(screen, t, fontM.Face, g.screenWidth/2-textLen(t)*int(fontM.FaceOptions.Size)/2, 70, color.White)
+
+	fastest := "-"
+	if s.Hits > 0 {
+		fastest = fmt.Sprintf("%.1fs", float64(s.FastestKill)/60)
+	}
+	items := []struct{ label, value string }{
+		{m.Shots, fmt.Sprint(s.Shots)},
+		{m.Hits, fmt.Sprint(s.Hits)},
+		{m.Accuracy, fmt.Sprintf("%.0f%%", s.Accuracy()*100)},
+		{m.LongestCombo, fmt.Sprint(s.LongestCombo)},
+		{m.FastestKill, fastest},
+	}
+	y := resultsItemY
+	for _, item := range items {
+		text.Draw(screen, item.label, fontS.Face, resultsLabelX, y, color.White)
+		text.Draw(screen, item.value, fontS.Face, resultsValueX, y, color.RGBA{0xff, 0xe0, 0, 0xff})
+		y += resultsItemHeight
+	}
+
+	// Kills per enemy kind with the sprites
+	y += 10
+	for i, kind := range core.EnemyKinds {
+		x := resultsLabelX + i*(g.screenWidth-resultsLabelX*2)/len(core.EnemyKinds)
+		image := enemyImages(kind)[0]
+		w, _ := image.Size()
+		drawutil.DrawImage(screen, image, float64(x+16), float64(y-6), &drawutil.DrawImageOption{
+			ScaleX:       32 / float64(w),
+			ScaleY:       32 / float64(w),
+			BasePosition: drawutil.DrawImagePositionCenter,
+		})
+		text.Draw(screen, fmt.Sprintf("x%d", s.Kills[kind]), fontS.Face, x+40, y, color.White)
+	}
+
+	g.drawSparkline(screen, s.ScoreSamples, float64(g.screenWidth-sparklineWidth)/2, float64(y+30))
+}
+
+// drawSparkline draws the line of the scores scaled into the box at x, y.
+// The samples are placed by their time, the box spanning the whole round.
+func (g *Game) drawSparkline(screen *ebiten.Image, samples []ScoreSample, x, y float64) {
+	ebitenutil.DrawRect(screen, x, y, sparklineWidth, sparklineHeight, color.RGBA{0xff, 0xff, 0xff, 0x20})
+
+	if len(samples) < 2 {
+		return
+	}
+
+	maxScore := 1
+	for _, v := range samples {
+		if v.Score > maxScore {
+			maxScore = v.Score
+		}
+	}
+
+	point := func(i int) (float64, float64) {
+		return x + float64(samples[i].TimeInTicks)*sparklineWidth/core.FinishTimeInTicks,
+			y + sparklineHeight - float64(samples[i].Score)*sparklineHeight/float64(maxScore)
+	}
+	for i := 1; i < len(samples); i++ {
+		x0, y0 := point(i - 1)
+		x1, y1 := point(i)
+		ebitenutil.DrawLine(screen, x0, y0, x1, y1, color.RGBA{0xa0, 0xff, 0xa0, 0xff})
+	}
+
+	t := fmt.Sprint(samples[len(samples)-1].Score)
+	text.Draw(screen, t, fontS.Face, int(x)+sparklineWidth+10, int(y)+10, color.White)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+const bytesPerStereoFrame = 4
+
+// StereoStream reads decoded PCM (16-bit little endian, 2 channels),
+// applying the gain to each channel. The gains can be changed while the
+// stream is being played.
+type StereoStream struct {
+	mu                  sync.Mutex
+	data                []byte
+	pos                 int64
+	leftGain, rightGain float64
+}
+
+func newStereoStream(data []byte) *StereoStream {
+	return &StereoStream{
+		data:      data,
+		leftGain:  1,
+		rightGain: 1,
+	}
+}
+
+// SetPan sets the position in the stereo field from -1 (left) to 1 (right)
+// by the constant power law, and the overall gain. A channel gets the whole
+// gain at hard pan and -3 dB at the center, so the samples are never
+// amplified to clip.
+func (s *StereoStream) SetPan(pan, gain float64) {
+	pan = math.Max(-1, math.Min(1, pan))
+	theta := (pan + 1) * math.Pi / 4
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.leftGain = math.Cos(theta) * gain
+	s.rightGain = math.Sin(theta) * gain
+}
+
+func (s *StereoStream) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pos >= int64(len(s.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p[:len(p)/bytesPerStereoFrame*bytesPerStereoFrame], s.data[s.pos:])
+	for i := 0; i+bytesPerStereoFrame <= n; i += bytesPerStereoFrame {
+		for ch, gain := range [2]float64{s.leftGain, s.rightGain} {
+			j := i + ch*2
+			v := float64(int16(uint16(p[j]) | uint16(p[j+1])<<8))
+			v = math.Max(math.MinInt16, math.Min(math.MaxInt16, v*gain))
+			u := uint16(int16(v))
+			p[j] = byte(u)
+			p[j+1] = byte(u >> 8)
+		}
+	}
+	s.pos += int64(n)
+
+	return n, nil
+}
+
+func (s *StereoStream) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = s.pos + offset
+	case io.SeekEnd:
+		pos = int64(len(s.data)) + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	s.pos = pos / bytesPerStereoFrame * bytesPerStereoFrame
+
+	return s.pos, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStereoPanGain(t *testing.T) {
+	for _, c := range []struct {
+		pan         float64
+		left, right float64
+	}{
+		{-1, 1, 0},
+		{0, math.Sqrt2 / 2, math.Sqrt2 / 2},
+		{1, 0, 1},
+		{2, 0, 1},
+	} {
+		s := newStereoStream(nil)
+		s.SetPan(c.pan, 1)
+		if math.Abs(s.leftGain-c.left) > 1e-9 || math.Abs(s.rightGain-c.right) > 1e-9 {
+			t.Errorf("pan %v: gains (%v, %v), want (%v, %v)", c.pan, s.leftGain, s.rightGain, c.left, c.right)
+		}
+	}
+}
+
+func TestStereoReadDoesNotClip(t *testing.T) {
+	// Loud samples on both channels, panned hard right
+	data := []byte{0x30, 0x75, 0xd0, 0x8a}
+	s := newStereoStream(append([]byte(nil), data...))
+	s.SetPan(1, 1)
+
+	p := make([]byte, len(data))
+	if _, err := s.Read(p); err != nil {
+		t.Fatal(err)
+	}
+	left := int16(uint16(p[0]) | uint16(p[1])<<8)
+	right := int16(uint16(p[2]) | uint16(p[3])<<8)
+	if left != 0 || right != -30000 {
+		t.Errorf("read (%d, %d), want (0, -30000)", left, right)
+	}
+}
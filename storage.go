@@ -0,0 +1,37 @@
+//go:build !js
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func storageFilePath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, gameName, name+".json"), nil
+}
+
+// loadData reads the data saved by the name.
+func loadData(name string) ([]byte, error) {
+	path, err := storageFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// saveData saves the data by the name, in the user config directory.
+func saveData(name string, data []byte) error {
+	path, err := storageFilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
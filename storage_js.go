@@ -0,0 +1,44 @@
+//go:build js
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+)
+
+func storageKey(name string) string {
+	return gameName + "." + name
+}
+
+func localStorage() (js.Value, error) {
+	s := js.Global().Get("localStorage")
+	if s.IsUndefined() || s.IsNull() {
+		return js.Value{}, errors.New("localStorage is not available")
+	}
+	return s, nil
+}
+
+// loadData reads the data saved by the name.
+func loadData(name string) ([]byte, error) {
+	s, err := localStorage()
+	if err != nil {
+		return nil, err
+	}
+	v := s.Call("getItem", storageKey(name))
+	if v.IsNull() {
+		return nil, fmt.Errorf("%s is not saved", name)
+	}
+	return []byte(v.String()), nil
+}
+
+// saveData saves the data by the name, in the local storage.
+func saveData(name string, data []byte) error {
+	s, err := localStorage()
+	if err != nil {
+		return err
+	}
+	s.Call("setItem", storageKey(name), string(data))
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package telemetry defines the events the game logs, in the wire format
+// of the logging server payloads. The field names are the ones of the
+// older untyped logs, so that the tools read both.
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tsujio/game-archerfish/core"
+)
+
+// Version is the version of the wire format, sent with every event. The
+// logs before it was introduced decode as version 0.
+const Version = 1
+
+// Header is the part common to the events.
+type Header struct {
+	Version    int             `json:"version"`
+	PlayerID   string          `json:"player_id"`
+	PlayID     string          `json:"play_id"`
+	Difficulty core.Difficulty `json:"difficulty,omitempty"`
+	// Action names the kind of the event, empty for the touch batches
+	Action string `json:"action,omitempty"`
+}
+
+func (h *Header) header() *Header {
+	return h
+}
+
+// Event is an event in the wire format. The events are the types of this
+// package only.
+type Event interface {
+	header() *Header
+	action() string
+}
+
+// SessionInit is sent when a play is initialized, with the seed and the
+// version of the rules of its world, and the settings the touches act by.
+// The rules and the settings are missing in the older logs. Daily is the
+// date of the daily challenge, if the play is one.
+type SessionInit struct {
+	Header
+	Seed          int64  `json:"seed"`
+	Rules         int    `json:"rules,omitempty"`
+	ScreenWidth   int    `json:"screen_width,omitempty"`
+	ControlScheme string `json:"control_scheme,omitempty"`
+	Daily         string `json:"daily,omitempty"`
+}
+
+// GameStart is sent when a round starts.
+type GameStart struct {
+	Header
+	SightMode string `json:"sight_mode"`
+}
+
+// Heartbeat is sent every 600 ticks while playing.
+type Heartbeat struct {
+	Header
+	Ticks uint64 `json:"ticks"`
+	Score int    `json:"score"`
+}
+
+// Shot is sent when a bullet is shot, with its initial velocity.
+type Shot struct {
+	Header
+	Ticks uint64  `json:"ticks"`
+	VX    float64 `json:"vx"`
+	VY    float64 `json:"vy"`
+	VZ    float64 `json:"vz"`
+}
+
+// Hit is sent when a bullet hits an enemy. Gain is the score of the enemy
+// and Score the one of the round after it.
+type Hit struct {
+	Header
+	Ticks     uint64 `json:"ticks"`
+	EnemyKind string `json:"enemy_kind"`
+	Gain      int    `json:"gain"`
+	Score     int    `json:"score"`
+}
+
+// GameOver is sent when a round finishes.
+type GameOver struct {
+	Header
+	Score     int    `json:"score"`
+	Shots     int    `json:"shots"`
+	Hits      int    `json:"hits"`
+	SightMode string `json:"sight_mode"`
+}
+
+// TouchRecord is the state of the touch in a tick. Mode and PlayID tell
+// the screen and the play it was on, as the ticks restart on each mode;
+// they are missing in the older logs.
+type TouchRecord struct {
+	Ticks        uint64 `json:"ticks"`
+	JustTouched  bool   `json:"just_touched"`
+	JustReleased bool   `json:"just_released"`
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	Mode         string `json:"mode,omitempty"`
+	PlayID       string `json:"play_id,omitempty"`
+}
+
+// TouchBatch is sent with the touches buffered by a TouchBuffer.
+type TouchBatch struct {
+	Header
+	Touches []TouchRecord `json:"touches"`
+}
+
+func (*SessionInit) action() string { return "initialize" }
+func (*GameStart) action() string   { return "start_game" }
+func (*Heartbeat) action() string   { return "playing" }
+func (*Shot) action() string        { return "shot" }
+func (*Hit) action() string         { return "hit" }
+func (*GameOver) action() string    { return "game_over" }
+func (*TouchBatch) action() string  { return "" }
+
+// newEvents maps the actions to the constructors of the events.
+var newEvents = map[string]func() Event{
+	"initialize": func() Event { return &SessionInit{} },
+	"start_game": func() Event { return &GameStart{} },
+	"playing":    func() Event { return &Heartbeat{} },
+	"shot":       func() Event { return &Shot{} },
+	"hit":        func() Event { return &Hit{} },
+	"game_over":  func() Event { return &GameOver{} },
+	"":           func() Event { return &TouchBatch{} },
+}
+
+// Encoder stamps the events with the header of the current play.
+type Encoder struct {
+	PlayerID   string
+	PlayID     string
+	Difficulty core.Difficulty
+}
+
+// Stamp fills the header of the event, which is then ready to be sent as
+// it is.
+func (enc *Encoder) Stamp(e Event) Event {
+	*e.header() = Header{
+		Version:    Version,
+		PlayerID:   enc.PlayerID,
+		PlayID:     enc.PlayID,
+		Difficulty: enc.Difficulty,
+		Action:     e.action(),
+	}
+	return e
+}
+
+// Marshal stamps the event and encodes it.
+func (enc *Encoder) Marshal(e Event) ([]byte, error) {
+	return json.Marshal(enc.Stamp(e))
+}
+
+// ErrUnknownAction is returned by Decode for the events of the actions it
+// does not know, which may be from a newer version.
+var ErrUnknownAction = errors.New("unknown action")
+
+// Decode decodes an event. The type is chosen by the action.
+func Decode(data []byte) (Event, error) {
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+
+	newEvent, ok := newEvents[h.Action]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAction, h.Action)
+	}
+
+	e := newEvent()
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// HeaderOf returns the header of the event.
+func HeaderOf(e Event) Header {
+	return *e.header()
+}
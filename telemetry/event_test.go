@@ -0,0 +1,161 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tsujio/game-archerfish/core"
+	"github.com/tsujio/game-archerfish/telemetry"
+)
+
+var update = flag.Bool("update", false, "overwrite the golden files instead of checking them")
+
+const goldenDir = "../testdata/telemetry"
+
+var goldenEncoder = telemetry.Encoder{
+	PlayerID:   "player",
+	PlayID:     "play",
+	Difficulty: core.DifficultyNormal,
+}
+
+// goldenEvents are the samples of the events, by the golden file names.
+func goldenEvents() []struct {
+	name  string
+	event telemetry.Event
+} {
+	return []struct {
+		name  string
+		event telemetry.Event
+	}{
+		{"initialize", &telemetry.SessionInit{Seed: 20230108, Rules: 1, ScreenWidth: 640, ControlScheme: "pull", Daily: "2023-01-08"}},
+		{"start_game", &telemetry.GameStart{SightMode: "normal"}},
+		{"playing", &telemetry.Heartbeat{Ticks: 600, Score: 12}},
+		{"shot", &telemetry.Shot{Ticks: 640, VX: 1.5, VY: -6.25, VZ: 4}},
+		{"hit", &telemetry.Hit{Ticks: 680, EnemyKind: "shy", Gain: 3, Score: 15}},
+		{"game_over", &telemetry.GameOver{Score: 42, Shots: 30, Hits: 21, SightMode: "assisted"}},
+		{"touches", &telemetry.TouchBatch{Touches: []telemetry.TouchRecord{
+			{Ticks: 620, JustTouched: true, X: 320, Y: 360, Mode: "playing", PlayID: "play"},
+			{Ticks: 621, X: 318, Y: 372, Mode: "playing", PlayID: "play"},
+			{Ticks: 640, JustReleased: true, X: 310, Y: 420, Mode: "playing", PlayID: "play"},
+		}}},
+	}
+}
+
+// indent formats the JSON with sorted keys, as the golden files are.
+func indent(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(data, '\n')
+}
+
+// checkGolden compares the data with the file, or overwrites the file with
+// -update.
+func checkGolden(t *testing.T, name string, data []byte) {
+	t.Helper()
+	path := filepath.Join(goldenDir, name+".json")
+	if *update {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("the wire format differs from %s:\n%s", path, data)
+	}
+}
+
+func TestEventWireFormat(t *testing.T) {
+	for _, g := range goldenEvents() {
+		data, err := goldenEncoder.Marshal(g.event)
+		if err != nil {
+			t.Fatalf("%s: %v", g.name, err)
+		}
+		t.Run(g.name, func(t *testing.T) {
+			checkGolden(t, g.name, indent(t, data))
+		})
+	}
+}
+
+func TestSchemaWireFormat(t *testing.T) {
+	data, err := json.Marshal(telemetry.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "schema", indent(t, data))
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	for _, g := range goldenEvents() {
+		data, err := goldenEncoder.Marshal(g.event)
+		if err != nil {
+			t.Fatalf("%s: %v", g.name, err)
+		}
+
+		e, err := telemetry.Decode(data)
+		if err != nil {
+			t.Errorf("%s: %v", g.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(e, g.event) {
+			t.Errorf("%s: decodes to %+v, want %+v", g.name, e, g.event)
+		}
+		if redata, err := json.Marshal(e); err != nil || !bytes.Equal(redata, data) {
+			t.Errorf("%s: encodes back to %s, want %s", g.name, redata, data)
+		}
+	}
+}
+
+func TestDecodeLegacy(t *testing.T) {
+	// The logs were maps before the events were typed, without the version
+	e, err := telemetry.Decode([]byte(`{"action":"game_over","player_id":"p","play_id":"q","score":7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &telemetry.GameOver{
+		Header: telemetry.Header{Version: 0, PlayerID: "p", PlayID: "q", Action: "game_over"},
+		Score:  7,
+	}
+	if !reflect.DeepEqual(e, want) {
+		t.Errorf("decodes to %+v, want %+v", e, want)
+	}
+
+	e, err = telemetry.Decode([]byte(`{"player_id":"p","play_id":"q","touches":[{"ticks":3,"just_touched":true,"just_released":false,"x":1,"y":2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBatch := &telemetry.TouchBatch{
+		Header:  telemetry.Header{PlayerID: "p", PlayID: "q"},
+		Touches: []telemetry.TouchRecord{{Ticks: 3, JustTouched: true, X: 1, Y: 2}},
+	}
+	if !reflect.DeepEqual(e, wantBatch) {
+		t.Errorf("decodes to %+v, want %+v", e, wantBatch)
+	}
+}
+
+func TestDecodeUnknownAction(t *testing.T) {
+	_, err := telemetry.Decode([]byte(`{"version":2,"player_id":"p","play_id":"q","action":"warp"}`))
+	if !errors.Is(err, telemetry.ErrUnknownAction) {
+		t.Errorf("err = %v, want ErrUnknownAction", err)
+	}
+
+	if _, err := telemetry.Decode([]byte(`{"action":`)); err == nil || errors.Is(err, telemetry.ErrUnknownAction) {
+		t.Errorf("err = %v for a broken event, want a syntax error", err)
+	}
+}
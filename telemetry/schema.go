@@ -0,0 +1,91 @@
+package telemetry
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema returns the JSON Schema of the events, built from the types so
+// that it always follows them.
+func Schema() map[string]interface{} {
+	var actions []string
+	for a := range newEvents {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+
+	var oneOf []interface{}
+	for _, a := range actions {
+		e := newEvents[a]()
+		s := typeSchema(reflect.TypeOf(e).Elem())
+		s["title"] = reflect.TypeOf(e).Elem().Name()
+
+		props := s["properties"].(map[string]interface{})
+		if a == "" {
+			// The touch batches have no action
+			delete(props, "action")
+		} else {
+			props["action"] = map[string]interface{}{"const": a}
+			required := append(s["required"].([]string), "action")
+			sort.Strings(required)
+			s["required"] = required
+		}
+		props["version"] = map[string]interface{}{"const": Version}
+
+		oneOf = append(oneOf, s)
+	}
+
+	return map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "Archerfish telemetry event",
+		"oneOf":   oneOf,
+	}
+}
+
+// typeSchema returns the schema of the type. The fields of the embedded
+// structs are flattened, as encoding/json does.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		required := []string{}
+		addStructFields(t, props, &required)
+		sort.Strings(required)
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": props,
+			"required":   required,
+		}
+	default:
+		panic("telemetry: no schema for " + t.String())
+	}
+}
+
+func addStructFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			addStructFields(f.Type, props, required)
+			continue
+		}
+
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		props[tag[0]] = typeSchema(f.Type)
+		if len(tag) == 1 || tag[1] != "omitempty" {
+			*required = append(*required, tag[0])
+		}
+	}
+}
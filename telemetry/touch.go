@@ -0,0 +1,38 @@
+package telemetry
+
+const (
+	touchBatchSize = 60
+	touchIdleTicks = 60
+)
+
+// TouchBuffer batches the touch records. A batch is due when it is full or
+// the touches pause for a while, and the rest has to be taken on the mode
+// changes and at shutdown, so that no batch spans modes or plays and no
+// touch is left behind. The zero value is an empty buffer.
+type TouchBuffer struct {
+	records []TouchRecord
+}
+
+func (b *TouchBuffer) Add(r TouchRecord) {
+	b.records = append(b.records, r)
+}
+
+// Due reports whether the batch should be sent at the ticks from the
+// mode start.
+func (b *TouchBuffer) Due(ticks uint64) bool {
+	if len(b.records) == 0 {
+		return false
+	}
+	last := b.records[len(b.records)-1].Ticks
+	return len(b.records) >= touchBatchSize || ticks-last > touchIdleTicks
+}
+
+// Take empties the buffer into a batch, or returns nil if it is empty.
+func (b *TouchBuffer) Take() *TouchBatch {
+	if len(b.records) == 0 {
+		return nil
+	}
+	batch := &TouchBatch{Touches: b.records}
+	b.records = nil
+	return batch
+}
@@ -0,0 +1,75 @@
+package telemetry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTouchBufferDue(t *testing.T) {
+	var b TouchBuffer
+	if b.Due(1000) {
+		t.Error("an empty buffer is due")
+	}
+
+	b.Add(TouchRecord{Ticks: 100, JustTouched: true})
+	for _, c := range []struct {
+		ticks uint64
+		due   bool
+	}{
+		{100, false},
+		{100 + touchIdleTicks, false},
+		{100 + touchIdleTicks + 1, true},
+		// The ticks restart on a mode change, and the buffer has to be
+		// taken then. A late batch is sent rather than kept forever.
+		{99, true},
+		{0, true},
+	} {
+		if due := b.Due(c.ticks); due != c.due {
+			t.Errorf("Due(%d) after a touch at 100 = %v, want %v", c.ticks, due, c.due)
+		}
+	}
+
+	b = TouchBuffer{}
+	for i := uint64(1); i <= touchBatchSize; i++ {
+		b.Add(TouchRecord{Ticks: i})
+		if due := b.Due(i); due != (i == touchBatchSize) {
+			t.Errorf("Due with %d records = %v", i, due)
+		}
+	}
+}
+
+func TestTouchBufferTake(t *testing.T) {
+	var b TouchBuffer
+	if batch := b.Take(); batch != nil {
+		t.Errorf("Take of an empty buffer = %+v, want nil", batch)
+	}
+
+	records := []TouchRecord{
+		{Ticks: 1, JustTouched: true, X: 10, Y: 20, Mode: "title", PlayID: "a"},
+		{Ticks: 2, X: 11, Y: 21, Mode: "title", PlayID: "a"},
+		{Ticks: 3, JustReleased: true, X: 12, Y: 22, Mode: "title", PlayID: "a"},
+	}
+	for _, r := range records {
+		b.Add(r)
+	}
+
+	batch := b.Take()
+	if batch == nil || !reflect.DeepEqual(batch.Touches, records) {
+		t.Fatalf("Take = %+v, want the records %+v", batch, records)
+	}
+	if batch.action() != "" {
+		t.Errorf("the batch has the action %q", batch.action())
+	}
+	if b.Due(1000) {
+		t.Error("the buffer is due after Take")
+	}
+	if batch := b.Take(); batch != nil {
+		t.Errorf("the second Take = %+v, want nil", batch)
+	}
+
+	// The taken batch is not changed by the records added later
+	b.Add(TouchRecord{Ticks: 4})
+	if !reflect.DeepEqual(batch.Touches, records) {
+		t.Errorf("the batch is changed to %+v", batch.Touches)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	toastTicks  = 180
+	toastHeight = 20
+)
+
+// Toast is a short message which slides in at the bottom of the screen and
+// disappears after a while.
+type Toast struct {
+	ticks uint64
+	text  string
+	color color.Color
+}
+
+func (t *Toast) Update() {
+	t.ticks++
+}
+
+func (t *Toast) Draw(screen *ebiten.Image, screenWidth, index int) {
+	slide := 0.0
+	if t.ticks < 10 {
+		slide = float64(10-t.ticks) / 10 * toastHeight
+	} else if t.ticks > toastTicks-10 {
+		slide = float64(t.ticks-(toastTicks-10)) / 10 * toastHeight
+	}
+	y := float64(screenHeight-(index+1)*toastHeight) + slide
+
+	ebitenutil.DrawRect(screen, 0, y, float64(screenWidth), toastHeight, color.RGBA{0, 0, 0, 0xc0})
+	text.Draw(screen, t.text, fontS.Face, 10, int(y)+15, t.color)
+}
+
+func (g *Game) showToast(s string, c color.Color) {
+	g.toasts = append(g.toasts, Toast{
+		text:  s,
+		color: c,
+	})
+}
+
+func (g *Game) updateToasts() {
+	var toasts []Toast
+	for i := range g.toasts {
+		t := &g.toasts[i]
+		t.Update()
+		if t.ticks < toastTicks {
+			toasts = append(toasts, *t)
+		}
+	}
+	g.toasts = toasts
+}
+
+func (g *Game) drawToasts(screen *ebiten.Image) {
+	for i := range g.toasts {
+		g.toasts[i].Draw(screen, g.screenWidth, len(g.toasts)-1-i)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tsujio/game-util/touchutil"
+)
+
+type ScalingMode int
+
+const (
+	// ScalingModePixelPerfect scales by an integer factor when the screen
+	// is large enough, leaving margins around.
+	ScalingModePixelPerfect ScalingMode = iota
+	// ScalingModeSmooth scales to fill the screen by any factor.
+	ScalingModeSmooth
+)
+
+// Viewport maps the logical screen, on which the game is drawn, onto the
+// device screen. The logical screen is letterboxed so that the aspect
+// ratio is kept.
+//
+// The game is drawn in logical pixels, and the canvases are then enlarged
+// to the device resolution in two steps: by the nearest filter to the
+// integer multiple of the logical size just above the device size, and
+// from there by the linear filter down to the device size. The pixels of
+// the sprites and of the pixel font stay sharp squares of the same size at
+// any scale, with only their edges smoothed, as if they were rendered at
+// the device resolution. The device scale factor makes the canvases be
+// scaled onto device pixels rather than onto the CSS or OS pixels, so that
+// the browser or the OS does not scale the result once more and blur it.
+type Viewport struct {
+	mode             ScalingMode
+	scale            float64
+	offsetX, offsetY float64
+	worldCanvas      *ebiten.Image
+	uiCanvas         *ebiten.Image
+	// worldPrescaled and uiPrescaled are the canvases enlarged by the
+	// nearest filter, prescale times the logical size
+	prescale                    int
+	worldPrescaled, uiPrescaled *ebiten.Image
+}
+
+func newViewport(mode ScalingMode) *Viewport {
+	return &Viewport{
+		mode:  mode,
+		scale: 1,
+	}
+}
+
+// Layout returns the size of the device screen in device pixels and
+// updates the mapping from the logical screen of the given size.
+func (v *Viewport) Layout(outsideWidth, outsideHeight, logicalWidth, logicalHeight int) (int, int) {
+	s := ebiten.DeviceScaleFactor()
+	w, h := int(float64(outsideWidth)*s), int(float64(outsideHeight)*s)
+
+	scale := math.Min(float64(w)/float64(logicalWidth), float64(h)/float64(logicalHeight))
+	if v.mode == ScalingModePixelPerfect && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+	v.scale = scale
+	v.offsetX = (float64(w) - float64(logicalWidth)*scale) / 2
+	v.offsetY = (float64(h) - float64(logicalHeight)*scale) / 2
+
+	return w, h
+}
+
+func (v *Viewport) ToLogicalPosition(pos touchutil.TouchPosition) touchutil.TouchPosition {
+	return touchutil.TouchPosition{
+		X: int(math.Floor((float64(pos.X) - v.offsetX) / v.scale)),
+		Y: int(math.Floor((float64(pos.Y) - v.offsetY) / v.scale)),
+	}
+}
+
+// Canvases returns offscreen images of the logical size, for the world and
+// for the UI drawn over it.
+func (v *Viewport) Canvases(logicalWidth, logicalHeight int) (world, ui *ebiten.Image) {
+	if v.worldCanvas != nil {
+		if w, h := v.worldCanvas.Size(); w != logicalWidth || h != logicalHeight {
+			v.worldCanvas.Dispose()
+			v.uiCanvas.Dispose()
+			v.worldCanvas, v.uiCanvas = nil, nil
+		}
+	}
+	if v.worldCanvas == nil {
+		v.worldCanvas = ebiten.NewImage(logicalWidth, logicalHeight)
+		v.uiCanvas = ebiten.NewImage(logicalWidth, logicalHeight)
+	}
+
+	v.worldCanvas.Clear()
+	v.uiCanvas.Clear()
+
+	return v.worldCanvas, v.uiCanvas
+}
+
+// prescaledCanvases returns the images the canvases are enlarged into by
+// the nearest filter, which are reallocated when the logical size or the
+// scale changes.
+func (v *Viewport) prescaledCanvases() (world, ui *ebiten.Image) {
+	prescale := int(math.Max(1, math.Ceil(v.scale)))
+	w, h := v.worldCanvas.Size()
+	w, h = w*prescale, h*prescale
+
+	if v.worldPrescaled != nil {
+		if pw, ph := v.worldPrescaled.Size(); pw != w || ph != h {
+			v.worldPrescaled.Dispose()
+			v.uiPrescaled.Dispose()
+			v.worldPrescaled, v.uiPrescaled = nil, nil
+		}
+	}
+	if v.worldPrescaled == nil {
+		v.worldPrescaled = ebiten.NewImage(w, h)
+		v.uiPrescaled = ebiten.NewImage(w, h)
+	}
+	v.prescale = prescale
+
+	return v.worldPrescaled, v.uiPrescaled
+}
+
+func (v *Viewport) Present(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+
+	worldPrescaled, uiPrescaled := v.prescaledCanvases()
+
+	// The rest of the scale is at most 1, which is exact in the pixel
+	// perfect mode
+	rest := v.scale / float64(v.prescale)
+	filter := ebiten.FilterLinear
+	if rest == 1 {
+		filter = ebiten.FilterNearest
+	}
+
+	for _, c := range []struct {
+		canvas, prescaled *ebiten.Image
+	}{
+		{v.worldCanvas, worldPrescaled},
+		{v.uiCanvas, uiPrescaled},
+	} {
+		o := &ebiten.DrawImageOptions{}
+		o.GeoM.Scale(float64(v.prescale), float64(v.prescale))
+		o.CompositeMode = ebiten.CompositeModeCopy
+		c.prescaled.DrawImage(c.canvas, o)
+
+		o = &ebiten.DrawImageOptions{}
+		o.GeoM.Scale(rest, rest)
+		o.GeoM.Translate(v.offsetX, v.offsetY)
+		o.Filter = filter
+		screen.DrawImage(c.prescaled, o)
+	}
+}